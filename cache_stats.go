@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+
+	"songsapi/logger"
+	"songsapi/storage"
+)
+
+type CacheStatsHandler struct {
+	Songs		*storage.CachedStorage[storage.Song]
+}
+
+type CacheStatsResponse struct {
+	Songs		storage.CacheStats
+}
+
+// @Tags admin
+// @Summary Returns hit and miss counters of the storage cache
+// @Router /admin/cache [get]
+// @Security AdminToken
+// @Failure 401
+// @Produce json
+// @Success 200 {object} CacheStatsResponse
+func (h *CacheStatsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodDelete {
+		h.purge(w)
+		return
+	}
+
+	Render(w, r, &CacheStatsResponse{ Songs: h.Songs.Stats() })
+}
+
+// @Tags admin
+// @Summary Drops everything kept in the storage cache
+// @Router /admin/cache [delete]
+// @Security AdminToken
+// @Failure 401
+// @Success 204
+func (h *CacheStatsHandler) purge(w http.ResponseWriter) {
+	h.Songs.Purge()
+	logger.Info.Println("storage cache purged")
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,168 @@
+package changefeed
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"songsapi/config"
+	"songsapi/logger"
+	"songsapi/storage"
+
+	"github.com/lib/pq"
+)
+
+// Channel is the Postgres NOTIFY channel of change_log inserts.
+const Channel = "change_log"
+
+type Config struct {
+	PollInterval	time.Duration
+	BatchSize		int
+	Buffer			int
+	Retention		time.Duration
+	Heartbeat		time.Duration
+}
+
+// Feed follows the change log and fans new changes out to subscribers. It is woken
+// up by LISTEN on the change_log channel and always reads changes from the table,
+// so every replica emits the same changes in the id order, whichever replica made them.
+type Feed struct {
+	Changes		storage.ChangeLogStorage
+	ConnString	string
+	Config		Config
+
+	mu			sync.Mutex
+	subscribers	map[chan *storage.Change]struct{}
+	lastId		int64
+}
+
+func ConfigFromEnv() Config {
+	return Config{
+		PollInterval: config.Duration("CHANGES_POLL_INTERVAL", 30 * time.Second),
+		BatchSize: config.Int("CHANGES_BATCH_SIZE", 500),
+		Buffer: config.Int("CHANGES_SUBSCRIBER_BUFFER", 256),
+		Retention: config.Duration("CHANGES_RETENTION", 7 * 24 * time.Hour),
+		Heartbeat: config.Duration("CHANGES_HEARTBEAT", 15 * time.Second),
+	}
+}
+
+func NewFeed(changes storage.ChangeLogStorage, connString string, cfg Config) *Feed {
+	return &Feed{
+		Changes: changes,
+		ConnString: connString,
+		Config: cfg,
+		subscribers: make(map[chan *storage.Change]struct{}),
+	}
+}
+
+// Start begins to follow the log from its current end. The poll interval covers
+// notifications lost while the listener reconnects.
+func (f *Feed) Start(ctx context.Context) error {
+	lastId, err := f.Changes.LastChangeId(ctx)
+	if err != nil {
+		return err
+	}
+	f.lastId = lastId
+
+	listener := pq.NewListener(f.ConnString, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Warn.Println("change log listener - ", err)
+		}
+	})
+	if err := listener.Listen(Channel); err != nil {
+		listener.Close()
+		return err
+	}
+
+	logger.Debug.Printf("following change log from id %d...\n", lastId)
+	go f.run(ctx, listener)
+	return nil
+}
+
+// Subscribe returns a channel of new changes and the function to unsubscribe.
+// The channel is closed when the subscriber falls behind by more than the buffer,
+// such subscriber should catch up from the change log.
+func (f *Feed) Subscribe() (<-chan *storage.Change, func()) {
+	ch := make(chan *storage.Change, f.Config.Buffer)
+
+	f.mu.Lock()
+	f.subscribers[ch] = struct{}{}
+	f.mu.Unlock()
+
+	return ch, func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subscribers[ch]; ok {
+			delete(f.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (f *Feed) run(ctx context.Context, listener *pq.Listener) {
+	defer listener.Close()
+
+	poll := time.NewTicker(f.Config.PollInterval)
+	defer poll.Stop()
+	prune := time.NewTicker(time.Hour)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-listener.Notify:
+		case <-poll.C:
+		case <-prune.C:
+			f.prune(ctx)
+			continue
+		}
+
+		f.fetch(ctx)
+	}
+}
+
+func (f *Feed) fetch(ctx context.Context) {
+	for {
+		changes, err := f.Changes.ChangesSince(ctx, f.lastId, f.Config.BatchSize)
+		if err != nil || len(changes) == 0 {
+			return
+		}
+
+		// change log ids are assigned in commit order, see ChangeLogTable.Publish,
+		// so no lower id can appear after lastId has moved past it
+		for _, change := range changes {
+			f.broadcast(change)
+			f.lastId = change.Id
+		}
+
+		if len(changes) < f.Config.BatchSize {
+			return
+		}
+	}
+}
+
+func (f *Feed) broadcast(change *storage.Change) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.subscribers {
+		select {
+		case ch <- change:
+		default:
+			logger.Warn.Println("change stream subscriber is too slow, dropping it")
+			delete(f.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (f *Feed) prune(ctx context.Context) {
+	if f.Config.Retention <= 0 {
+		return
+	}
+
+	if deleted, err := f.Changes.PruneChanges(ctx, f.Config.Retention); err == nil && deleted > 0 {
+		logger.Info.Printf("%d old changes deleted from change log\n", deleted)
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"songsapi/changefeed"
+	"songsapi/logger"
+	"songsapi/storage"
+)
+
+type ChangeStreamHandler struct {
+	Changes		storage.ChangeLogStorage
+	Feed		*changefeed.Feed
+	Heartbeat	time.Duration
+}
+
+// ChangeStreamReset is the data of reset event: changes after Last-Event-ID were pruned from the log,
+// the client should reload songs and groups, the stream goes on with changes after LastId.
+type ChangeStreamReset struct {
+	OldestId	int64	`json:"oldestId"`
+	LastId		int64	`json:"lastId"`
+}
+
+// @Tags changes
+// @Summary Streams song and group changes as Server-Sent Events
+// @Description Every event has id, event name (song.created, song.updated, song.deleted, group.created, group.updated,
+// @Description group.deleted or group.merged) and storage.Change as data. With Last-Event-ID header (sent by EventSource
+// @Description on reconnect) or lastEventId query changes made after that id are replayed first, while they are kept in the log.
+// @Description When some of them are already pruned, a reset event with ChangeStreamReset data is sent instead of the replay.
+// @Router /changes/stream [get]
+// @Produce text/event-stream
+// @Param Last-Event-ID header int false "Id of the last received change"
+// @Param lastEventId query int false "Same as Last-Event-ID header"
+// @Success 200 {object} storage.Change
+// @Failure 400
+// @Failure 500
+func (h *ChangeStreamHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	lastEventId := r.Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = r.URL.Query().Get("lastEventId")
+	}
+
+	resume := lastEventId != ""
+	sentId, err := strconv.ParseInt(lastEventId, 10, 64)
+	if resume && (err != nil || sentId < 0) {
+		http.Error(w, "Last-Event-ID must be a change id", http.StatusBadRequest)
+		return
+	}
+
+	// subscribe before replay, so changes made in between are not lost
+	changes, unsubscribe := h.Feed.Subscribe()
+	defer unsubscribe()
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	if resume {
+		reset, err := h.resetSince(r.Context(), sentId)
+		if err != nil {
+			return
+		}
+		if reset != nil {
+			if err := writeReset(w, reset); err != nil {
+				return
+			}
+			sentId, resume = reset.LastId, false
+		}
+	}
+
+	for resume {
+		replay, err := h.Changes.ChangesSince(r.Context(), sentId, 500)
+		if err != nil {
+			return
+		}
+		for _, change := range replay {
+			if err := writeChange(w, change); err != nil {
+				return
+			}
+			sentId = change.Id
+		}
+		resume = len(replay) == 500
+	}
+
+	if err := controller.Flush(); err != nil {
+		logger.Err.Println("change stream can't be flushed - ", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(h.Heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+
+		case change, ok := <-changes:
+			if !ok {
+				// the client reconnects with Last-Event-ID and catches up from the log
+				return
+			}
+			if change.Id <= sentId {
+				continue
+			}
+			if err := writeChange(w, change); err != nil {
+				return
+			}
+			sentId = change.Id
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// resetSince returns the reset when changes after sentId are missing from the log, nil if all of them are kept.
+func (h *ChangeStreamHandler) resetSince(ctx context.Context, sentId int64) (*ChangeStreamReset, error) {
+	oldestId, err := h.Changes.FirstChangeId(ctx)
+	if err != nil || oldestId <= sentId + 1 {
+		return nil, err
+	}
+
+	lastId, err := h.Changes.LastChangeId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ChangeStreamReset{ OldestId: oldestId, LastId: lastId }, nil
+}
+
+func writeReset(w http.ResponseWriter, reset *ChangeStreamReset) error {
+	data, err := json.Marshal(reset)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: reset\ndata: %s\n\n", reset.LastId, data)
+	return err
+}
+
+func writeChange(w http.ResponseWriter, change *storage.Change) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", change.Id, change.Event, data)
+	return err
+}
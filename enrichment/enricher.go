@@ -0,0 +1,219 @@
+package enrichment
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"songsapi/config"
+	"songsapi/infoapi"
+	"songsapi/logger"
+	"songsapi/storage"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("songsapi/enrichment")
+
+type Config struct {
+	Workers			int
+	PollInterval	time.Duration
+	Lease			time.Duration
+	MaxAttempts		int
+	RetryBackoff	time.Duration
+	MaxBackoff		time.Duration
+}
+
+// Enricher is a pool of workers filling songs with info API data in background.
+// Jobs are kept in the database, so they survive restarts and are shared between replicas.
+type Enricher struct {
+	Songs		storage.Storage[storage.Song]
+	Groups		storage.Storage[storage.Group]
+	Jobs		storage.JobStorage
+	InfoAPI		infoapi.SongInfoProvider
+	Config		Config
+	// SongsCache is purged after pending songs are inserted around it, it may be nil
+	SongsCache	storage.Purger
+
+	wake		chan struct{}
+}
+
+func ConfigFromEnv() Config {
+	return Config{
+		Workers: config.Int("ENRICHMENT_WORKERS", 4),
+		PollInterval: config.Duration("ENRICHMENT_POLL_INTERVAL", 5 * time.Second),
+		Lease: config.Duration("ENRICHMENT_LEASE", 5 * time.Minute),
+		MaxAttempts: config.Int("ENRICHMENT_MAX_ATTEMPTS", 20),
+		RetryBackoff: config.Duration("ENRICHMENT_RETRY_BACKOFF", 10 * time.Second),
+		MaxBackoff: config.Duration("ENRICHMENT_MAX_BACKOFF", 30 * time.Minute),
+	}
+}
+
+func NewEnricher(songs storage.Storage[storage.Song], groups storage.Storage[storage.Group],
+				jobs storage.JobStorage, client infoapi.SongInfoProvider, cfg Config) *Enricher {
+	return &Enricher{
+		Songs: songs,
+		Groups: groups,
+		Jobs: jobs,
+		InfoAPI: client,
+		Config: cfg,
+		wake: make(chan struct{}, cfg.Workers),
+	}
+}
+
+// ApplyInfo copies info API data into the song, release date is converted from dd.mm.yyyy.
+// Fields upstream returns empty are left as they are, so stored lyrics and link are never wiped.
+func ApplyInfo(song *storage.Song, info *infoapi.SongInfo) error {
+	if info.ReleaseDate != "" {
+		parsedDate, err := time.Parse("02.01.2006", info.ReleaseDate)
+		if err != nil {
+			return fmt.Errorf("can't parse release date - %w", err)
+		}
+		song.ReleaseDate = parsedDate.Format("2006-01-02")
+	}
+
+	if info.Text != "" {
+		song.Text = info.Text
+	}
+	if info.Link != "" {
+		song.Link = info.Link
+	}
+	song.Status = storage.StatusEnriched
+	now := time.Now()
+	song.EnrichedAt = &now
+	return nil
+}
+
+func (e *Enricher) Start(ctx context.Context) {
+	logger.Debug.Printf("starting %d enrichment workers...\n", e.Config.Workers)
+	for i := 0; i < e.Config.Workers; i++ {
+		go e.work(ctx)
+	}
+}
+
+// Enqueue saves the song pending enrichment with its job and wakes up a worker.
+func (e *Enricher) Enqueue(ctx context.Context, song *storage.Song) (*storage.Job, error) {
+	job, err := e.Jobs.CreateJob(ctx, song)
+	if err != nil {
+		return nil, err
+	}
+	if e.SongsCache != nil {
+		e.SongsCache.Purge()
+	}
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+func (e *Enricher) work(ctx context.Context) {
+	ticker := time.NewTicker(e.Config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for e.runOnce(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-e.wake:
+		}
+	}
+}
+
+func (e *Enricher) runOnce(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	job, err := e.Jobs.ClaimJob(ctx, e.Config.Lease)
+	if err != nil {
+		return false
+	}
+
+	// every claimed job is a trace of its own, polls without a job aren't traced
+	ctx, span := tracer.Start(ctx, "enrichment.job", trace.WithAttributes(
+		attribute.Int("job.id", job.Id), attribute.Int("song.id", job.SongId), attribute.Int("job.attempt", job.Attempts)))
+	defer span.End()
+
+	retryAfter := e.process(ctx, job)
+	span.SetAttributes(attribute.String("job.status", job.Status))
+	if err := e.Jobs.FinishJob(ctx, job, retryAfter); err != nil {
+		logger.Err.Printf("can't save result of enrichment job %d - %v\n", job.Id, err)
+	}
+
+	return true
+}
+
+func (e *Enricher) process(ctx context.Context, job *storage.Job) time.Duration {
+	song, err := e.Songs.Get(ctx, job.SongId)
+	if err == sql.ErrNoRows {
+		job.Status, job.LastError = storage.JobFailed, "song was deleted"
+		return 0
+	}
+	if err != nil {
+		return e.retry(ctx, job, err)
+	}
+
+	group, err := e.Groups.Get(ctx, song.GroupId)
+	if err != nil {
+		return e.retry(ctx, job, err)
+	}
+
+	info, err := e.InfoAPI.SongInfo(ctx, group.Name, song.Name)
+	if err == nil {
+		err = ApplyInfo(song, info)
+	} else if infoapi.IsTemporary(err) {
+		return e.retry(ctx, job, err)
+	}
+
+	if err != nil {
+		e.fail(ctx, job, song, err)
+		return 0
+	}
+
+	if err := e.Songs.Update(ctx, song); err != nil {
+		return e.retry(ctx, job, err)
+	}
+
+	logger.Info.Printf("song %d enriched by job %d\n", song.Id, job.Id)
+	job.Status, job.LastError = storage.JobDone, ""
+	return 0
+}
+
+func (e *Enricher) retry(ctx context.Context, job *storage.Job, err error) time.Duration {
+	if e.Config.MaxAttempts > 0 && job.Attempts >= e.Config.MaxAttempts {
+		job.Status, job.LastError = storage.JobFailed, err.Error()
+		if song, getErr := e.Songs.Get(ctx, job.SongId); getErr == nil {
+			e.fail(ctx, job, song, err)
+		}
+		return 0
+	}
+
+	backoff := e.Config.RetryBackoff << min(job.Attempts - 1, 16)
+	if backoff > e.Config.MaxBackoff || backoff <= 0 {
+		backoff = e.Config.MaxBackoff
+	}
+
+	logger.Warn.Printf("enrichment job %d failed, retry in %s - %v\n", job.Id, backoff, err)
+	job.Status, job.LastError = storage.JobRetrying, err.Error()
+	return backoff
+}
+
+func (e *Enricher) fail(ctx context.Context, job *storage.Job, song *storage.Song, err error) {
+	logger.Err.Printf("enrichment job %d failed - %v\n", job.Id, err)
+	job.Status, job.LastError = storage.JobFailed, err.Error()
+
+	song.Status = storage.StatusEnrichmentFailed
+	if err := e.Songs.Update(ctx, song); err != nil {
+		logger.Err.Printf("can't mark song %d as failed - %v\n", song.Id, err)
+	}
+}
@@ -0,0 +1,212 @@
+package enrichment
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"songsapi/config"
+	"songsapi/infoapi"
+	"songsapi/logger"
+	"songsapi/storage"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type RefresherConfig struct {
+	Interval		time.Duration
+	MaxAge			time.Duration
+	IncompleteAge	time.Duration
+	BatchSize		int
+}
+
+// RunStats describes the last metadata refresh run.
+type RunStats struct {
+	StartedAt	time.Time					`json:"startedAt"`
+	FinishedAt	time.Time					`json:"finishedAt"`
+	Checked		int							`json:"checked"`
+	Updated		int							`json:"updated"`
+	Unchanged	int							`json:"unchanged"`
+	Failed		int							`json:"failed"`
+	LastError	string						`json:"lastError,omitempty"`
+	Changes		[]*storage.MetadataChange	`json:"changes"`
+}
+
+// Refresher periodically re-queries the info API for songs with old or incomplete
+// metadata, because upstream corrections never reach songs enriched once on insert.
+type Refresher struct {
+	Metadata	storage.MetadataRefreshStorage
+	InfoAPI		infoapi.SongInfoProvider
+	Config		RefresherConfig
+	// SongsCache is purged after songs are changed around it, it may be nil
+	SongsCache	storage.Purger
+
+	mu			sync.Mutex
+	lastRun		*RunStats
+	running		atomic.Bool
+	// ctx of Start, runs started by RunAsync end with it
+	ctx			context.Context
+}
+
+func RefresherConfigFromEnv() RefresherConfig {
+	return RefresherConfig{
+		Interval: config.Duration("REFRESH_INTERVAL", time.Hour),
+		MaxAge: config.Duration("REFRESH_MAX_AGE", 30 * 24 * time.Hour),
+		IncompleteAge: config.Duration("REFRESH_INCOMPLETE_AGE", 24 * time.Hour),
+		BatchSize: config.Int("REFRESH_BATCH_SIZE", 100),
+	}
+}
+
+// Start runs the refresh every Interval until ctx is done, ctx is also used by RunAsync.
+func (r *Refresher) Start(ctx context.Context) {
+	r.ctx = ctx
+	if r.Config.Interval <= 0 {
+		logger.Debug.Println("metadata refresh is disabled")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(r.Config.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.Run(ctx)
+			}
+		}
+	}()
+}
+
+// Running reports whether a refresh run is in progress.
+func (r *Refresher) Running() bool {
+	return r.running.Load()
+}
+
+// LastRun returns stats of the last finished run, nil if there was no run yet.
+func (r *Refresher) LastRun() *RunStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lastRun
+}
+
+// Run refreshes one batch of stale songs, it returns nil if another run is in progress.
+func (r *Refresher) Run(ctx context.Context) *RunStats {
+	if !r.running.CompareAndSwap(false, true) {
+		return nil
+	}
+	defer r.running.Store(false)
+
+	return r.run(ctx)
+}
+
+// RunAsync claims the run and refreshes in background with the ctx of Start,
+// it returns false if another run is in progress.
+func (r *Refresher) RunAsync() bool {
+	if !r.running.CompareAndSwap(false, true) {
+		return false
+	}
+
+	go func() {
+		defer r.running.Store(false)
+		r.run(r.ctx)
+	}()
+	return true
+}
+
+func (r *Refresher) run(ctx context.Context) *RunStats {
+	ctx, span := tracer.Start(ctx, "metadata.refresh")
+	defer span.End()
+
+	stats := &RunStats{ StartedAt: time.Now(), Changes: make([]*storage.MetadataChange, 0) }
+
+	songs, err := r.Metadata.StaleSongs(ctx, r.Config.MaxAge, r.Config.IncompleteAge, r.Config.BatchSize)
+	if err != nil {
+		stats.LastError = err.Error()
+	}
+
+	for _, song := range songs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		stats.Checked++
+		changes, err := r.refresh(ctx, song)
+		if err != nil {
+			logger.Warn.Printf("can't refresh metadata of song %d - %v\n", song.Id, err)
+			stats.Failed++
+			stats.LastError = err.Error()
+			continue
+		}
+
+		if len(changes) == 0 {
+			stats.Unchanged++
+			continue
+		}
+
+		stats.Updated++
+		stats.Changes = append(stats.Changes, changes...)
+	}
+
+	if stats.Updated > 0 && r.SongsCache != nil {
+		r.SongsCache.Purge()
+	}
+
+	stats.FinishedAt = time.Now()
+	logger.Info.Printf("metadata refresh: checked %d, updated %d, failed %d\n", stats.Checked, stats.Updated, stats.Failed)
+
+	span.SetAttributes(attribute.Int("refresh.checked", stats.Checked), attribute.Int("refresh.updated", stats.Updated),
+		attribute.Int("refresh.failed", stats.Failed))
+
+	r.mu.Lock()
+	r.lastRun = stats
+	r.mu.Unlock()
+
+	return stats
+}
+
+func (r *Refresher) refresh(ctx context.Context, song *storage.Song) ([]*storage.MetadataChange, error) {
+	info, err := r.InfoAPI.SongInfo(ctx, song.Group, song.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed := *song
+	if err := ApplyInfo(&refreshed, info); err != nil {
+		return nil, err
+	}
+
+	changes := make([]*storage.MetadataChange, 0)
+	addChange := func(field, oldValue, newValue string) {
+		changes = append(changes, &storage.MetadataChange{ SongId: song.Id, Field: field, OldValue: oldValue, NewValue: newValue })
+	}
+
+	if datePart(song.ReleaseDate) != datePart(refreshed.ReleaseDate) {
+		addChange("releaseDate", datePart(song.ReleaseDate), datePart(refreshed.ReleaseDate))
+	}
+	if song.Link != refreshed.Link {
+		addChange("link", song.Link, refreshed.Link)
+	}
+	if song.Text != refreshed.Text {
+		addChange("text", song.Text, refreshed.Text)
+	}
+
+	// enrichedAt is updated even without changes, so the song isn't checked again until it is stale
+	if err := r.Metadata.RefreshSong(ctx, &refreshed, changes); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// datePart cuts the time from release date, stored dates are read as 2006-01-02T00:00:00Z.
+func datePart(date string) string {
+	if len(date) > len("2006-01-02") {
+		return date[:len("2006-01-02")]
+	}
+	return date
+}
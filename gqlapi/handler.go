@@ -0,0 +1,49 @@
+package gqlapi
+
+import (
+	_ "embed"
+	"net/http"
+	"time"
+
+	"songsapi/storage"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+//go:embed schema.graphql
+var schemaSource string
+
+// how long the loader waits for other resolvers before querying the batch
+const batchWait = 2 * time.Millisecond
+
+// deeper queries are rejected, every level of song { group { songs } } multiplies the work
+const maxDepth = 8
+
+// Handler serves POST /graphql, every request gets its own loaders.
+type Handler struct {
+	GroupBatches	storage.BatchGetter[storage.Group]
+
+	resolver	*Resolver
+	relay		*relay.Handler
+}
+
+func NewHandler(resolver *Resolver, groupBatches storage.BatchGetter[storage.Group]) (*Handler, error) {
+	schema, err := graphql.ParseSchema(schemaSource, resolver, graphql.MaxParallelism(50), graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{ GroupBatches: groupBatches, resolver: resolver, relay: &relay.Handler{ Schema: schema } }, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := withLoaders(r.Context(), &loaders{
+		groups: GroupLoader(r.Context(), h.GroupBatches, batchWait),
+		aliases: AliasesLoader(r.Context(), h.resolver.AliasesTable, batchWait),
+		newSongs: func(page, limit int) *Loader[[]*storage.Song] {
+			return GroupSongsLoader(r.Context(), h.resolver.GroupSongs, page, limit, batchWait)
+		},
+	})
+	h.relay.ServeHTTP(w, r.WithContext(ctx))
+}
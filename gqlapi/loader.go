@@ -0,0 +1,162 @@
+package gqlapi
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"songsapi/logger"
+	"songsapi/storage"
+)
+
+// Loader collects ids requested by concurrently running resolvers during wait and loads
+// them with one query, so a list of songs with their groups doesn't query every group.
+// Results are kept until the end of the request.
+type Loader[T any] struct {
+	fetch		func(ids []int) (map[int]*T, error)
+	wait		time.Duration
+
+	mu			sync.Mutex
+	results		map[int]*loaderResult[T]
+	pending		[]int
+}
+
+type loaderResult[T any] struct {
+	value		*T
+	err			error
+	done		chan struct{}
+}
+
+func NewLoader[T any](wait time.Duration, fetch func(ids []int) (map[int]*T, error)) *Loader[T] {
+	return &Loader[T]{ fetch: fetch, wait: wait, results: make(map[int]*loaderResult[T]) }
+}
+
+// Load waits for the batch with the id, missing records are reported with sql.ErrNoRows.
+func (l *Loader[T]) Load(ctx context.Context, id int) (*T, error) {
+	l.mu.Lock()
+	result, ok := l.results[id]
+	if !ok {
+		result = &loaderResult[T]{ done: make(chan struct{}) }
+		l.results[id] = result
+		l.pending = append(l.pending, id)
+		if len(l.pending) == 1 {
+			time.AfterFunc(l.wait, l.dispatch)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-result.done:
+		return result.value, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *Loader[T]) dispatch() {
+	l.mu.Lock()
+	ids := l.pending
+	l.pending = nil
+	batch := make([]*loaderResult[T], len(ids))
+	for i, id := range ids {
+		batch[i] = l.results[id]
+	}
+	l.mu.Unlock()
+
+	values, err := l.fetch(ids)
+	logger.Debug.Printf("loader fetched %d records in one batch\n", len(ids))
+	for i, id := range ids {
+		batch[i].value, batch[i].err = values[id], err
+		if err == nil && batch[i].value == nil {
+			batch[i].err = sql.ErrNoRows
+		}
+		close(batch[i].done)
+	}
+}
+
+// GroupLoader batches groups by id with storage.BatchGetter, batches are loaded within ctx of the request.
+func GroupLoader(ctx context.Context, groups storage.BatchGetter[storage.Group], wait time.Duration) *Loader[storage.Group] {
+	return NewLoader(wait, func(ids []int) (map[int]*storage.Group, error) {
+		found, err := groups.GetMany(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+
+		byId := make(map[int]*storage.Group, len(found))
+		for _, group := range found {
+			byId[group.Id] = group
+		}
+		return byId, nil
+	})
+}
+
+// GroupSongsLoader batches songs by group id, page and limit are the same for the whole batch.
+func GroupSongsLoader(ctx context.Context, songs storage.GroupSongsGetter, page, limit int, wait time.Duration) *Loader[[]*storage.Song] {
+	return NewLoader(wait, func(ids []int) (map[int]*[]*storage.Song, error) {
+		found, err := songs.SongsOfGroups(ctx, ids, page, limit)
+		if err != nil {
+			return nil, err
+		}
+		return everyId(ids, found), nil
+	})
+}
+
+// AliasesLoader batches aliases by group id.
+func AliasesLoader(ctx context.Context, aliases storage.AliasStorage, wait time.Duration) *Loader[[]*storage.GroupAlias] {
+	return NewLoader(wait, func(ids []int) (map[int]*[]*storage.GroupAlias, error) {
+		found, err := aliases.AliasesOf(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		return everyId(ids, found), nil
+	})
+}
+
+// everyId gives an empty list to ids without records, they aren't missing like absent groups.
+func everyId[T any](ids []int, found map[int][]T) map[int]*[]T {
+	byId := make(map[int]*[]T, len(ids))
+	for _, id := range ids {
+		list := found[id]
+		if list == nil {
+			list = make([]T, 0)
+		}
+		byId[id] = &list
+	}
+	return byId
+}
+
+type loadersKey struct{}
+
+type loaders struct {
+	groups		*Loader[storage.Group]
+	aliases		*Loader[[]*storage.GroupAlias]
+	newSongs	func(page, limit int) *Loader[[]*storage.Song]
+
+	mu			sync.Mutex
+	songs		map[[2]int]*Loader[[]*storage.Song]
+}
+
+// groupSongs returns the loader of the page, groups asking for different pages are batched separately.
+func (l *loaders) groupSongs(page, limit int) *Loader[[]*storage.Song] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := [2]int{ page, limit }
+	if l.songs == nil {
+		l.songs = make(map[[2]int]*Loader[[]*storage.Song])
+	}
+	if _, ok := l.songs[key]; !ok {
+		l.songs[key] = l.newSongs(page, limit)
+	}
+	return l.songs[key]
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	l, _ := ctx.Value(loadersKey{}).(*loaders)
+	return l
+}
@@ -0,0 +1,350 @@
+package gqlapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"songsapi/lyrics"
+	"songsapi/query"
+	"songsapi/storage"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// Resolver is the root of the schema, it uses the same storages as REST handlers.
+type Resolver struct {
+	SongsTable		storage.Storage[storage.Song]
+	GroupsTable		storage.Storage[storage.Group]
+	AliasesTable	storage.AliasStorage
+	GroupSongs		storage.GroupSongsGetter
+}
+
+// lists are always paged like gRPC search does, so a single response stays bounded
+const (
+	defaultPage		= 1
+	defaultLimit	= 10
+	maxLimit		= 100
+)
+
+type songFilter struct {
+	Name		*string
+	Group		*string
+	ReleaseDate	*string
+	Text		*string
+	Link		*string
+}
+
+func (r *Resolver) Song(ctx context.Context, args struct{ ID graphql.ID }) (*songResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	song, err := r.SongsTable.Get(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &songResolver{ root: r, song: song }, nil
+}
+
+func (r *Resolver) Songs(ctx context.Context, args struct {
+	Filter	*songFilter
+	Page	*int32
+	Limit	*int32
+}) ([]*songResolver, error) {
+	page, limit, err := paging(args.Page, args.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	songQuery := &query.SongQuery{ Page: page, Limit: limit }
+	if filter := args.Filter; filter != nil {
+		songQuery.Name, songQuery.Group = stringValue(filter.Name), stringValue(filter.Group)
+		songQuery.ReleaseDate, songQuery.Text = stringValue(filter.ReleaseDate), stringValue(filter.Text)
+		songQuery.Link = stringValue(filter.Link)
+	}
+
+	if err := songQuery.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid songs filter - %w", err)
+	}
+
+	return r.findSongs(ctx, songQuery)
+}
+
+func (r *Resolver) Group(ctx context.Context, args struct{ ID graphql.ID }) (*groupResolver, error) {
+	id, err := parseID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := r.GroupsTable.Get(ctx, id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &groupResolver{ root: r, group: group }, nil
+}
+
+func (r *Resolver) Groups(ctx context.Context, args struct {
+	Name	*string
+	Exact	*bool
+}) ([]*groupResolver, error) {
+	groups, err := r.GroupsTable.Find(ctx, &query.GroupQuery{ Name: stringValue(args.Name), Exact: args.Exact != nil && *args.Exact })
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*groupResolver, len(groups))
+	for i, group := range groups {
+		resolvers[i] = &groupResolver{ root: r, group: group }
+	}
+	return resolvers, nil
+}
+
+func (r *Resolver) findSongs(ctx context.Context, songQuery *query.SongQuery) ([]*songResolver, error) {
+	songs, err := r.SongsTable.Find(ctx, songQuery)
+	if err == sql.ErrNoRows {
+		return []*songResolver{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*songResolver, len(songs))
+	for i, song := range songs {
+		resolvers[i] = &songResolver{ root: r, song: song }
+	}
+	return resolvers, nil
+}
+
+type songResolver struct {
+	root		*Resolver
+	song		*storage.Song
+}
+
+func (s *songResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(s.song.Id))
+}
+
+func (s *songResolver) Name() string {
+	return s.song.Name
+}
+
+func (s *songResolver) ReleaseDate() *string {
+	return optional(s.song.ReleaseDate)
+}
+
+func (s *songResolver) Text() string {
+	return s.song.Text
+}
+
+func (s *songResolver) Link() *string {
+	return optional(s.song.Link)
+}
+
+func (s *songResolver) Lang() *string {
+	return optional(s.song.Lang)
+}
+
+func (s *songResolver) Status() string {
+	return s.song.Status
+}
+
+// Group is loaded in batches with other songs of the response.
+func (s *songResolver) Group(ctx context.Context) (*groupResolver, error) {
+	var group *storage.Group
+	var err error
+	if loaders := loadersFrom(ctx); loaders != nil {
+		group, err = loaders.groups.Load(ctx, s.song.GroupId)
+	} else {
+		group, err = s.root.GroupsTable.Get(ctx, s.song.GroupId)
+	}
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &groupResolver{ root: s.root, group: group }, nil
+}
+
+type textPage struct {
+	unit		string
+	fragments	[]string
+	page		int32
+	limit		int32
+	total		int32
+}
+
+func (s *songResolver) TextPage(args struct {
+	Unit	*string
+	Page	*int32
+	Limit	*int32
+}) (*textPage, error) {
+	unit, ok := lyrics.ParseUnit(stringValue(args.Unit))
+	if !ok || unit == lyrics.SectionUnit {
+		return nil, errors.New("unit must be one of line, couplet, char")
+	}
+
+	page, limit, err := paging(args.Page, args.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	units := lyrics.Split(s.song.Text, unit)
+	offset := min(limit * (page - 1), len(units))
+	return &textPage{
+		unit: string(unit),
+		fragments: units[offset:min(offset + limit, len(units))],
+		page: int32(page),
+		limit: int32(limit),
+		total: int32(len(units)),
+	}, nil
+}
+
+func (p *textPage) Unit() string {
+	return p.unit
+}
+
+func (p *textPage) Fragments() []string {
+	return p.fragments
+}
+
+func (p *textPage) Page() int32 {
+	return p.page
+}
+
+func (p *textPage) Limit() int32 {
+	return p.limit
+}
+
+func (p *textPage) Total() int32 {
+	return p.total
+}
+
+type groupResolver struct {
+	root		*Resolver
+	group		*storage.Group
+}
+
+func (g *groupResolver) ID() graphql.ID {
+	return graphql.ID(strconv.Itoa(g.group.Id))
+}
+
+func (g *groupResolver) Name() string {
+	return g.group.Name
+}
+
+// Aliases are loaded in batches with other groups of the response.
+func (g *groupResolver) Aliases(ctx context.Context) ([]string, error) {
+	var aliases []*storage.GroupAlias
+	var err error
+	if loaders := loadersFrom(ctx); loaders != nil {
+		var loaded *[]*storage.GroupAlias
+		if loaded, err = loaders.aliases.Load(ctx, g.group.Id); err == nil {
+			aliases = *loaded
+		}
+	} else {
+		aliases, err = g.root.AliasesTable.Aliases(ctx, g.group.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(aliases))
+	for i, alias := range aliases {
+		names[i] = alias.Alias
+	}
+	return names, nil
+}
+
+// Songs are matched by group id, not by name, and loaded in batches with other groups asking for the same page.
+func (g *groupResolver) Songs(ctx context.Context, args struct {
+	Page	*int32
+	Limit	*int32
+}) ([]*songResolver, error) {
+	page, limit, err := paging(args.Page, args.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	var songs []*storage.Song
+	if loaders := loadersFrom(ctx); loaders != nil {
+		loaded, err := loaders.groupSongs(page, limit).Load(ctx, g.group.Id)
+		if err != nil {
+			return nil, err
+		}
+		songs = *loaded
+	} else {
+		found, err := g.root.GroupSongs.SongsOfGroups(ctx, []int{ g.group.Id }, page, limit)
+		if err != nil {
+			return nil, err
+		}
+		songs = found[g.group.Id]
+	}
+
+	resolvers := make([]*songResolver, len(songs))
+	for i, song := range songs {
+		resolvers[i] = &songResolver{ root: g.root, song: song }
+	}
+	return resolvers, nil
+}
+
+func parseID(id graphql.ID) (int, error) {
+	parsed, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, fmt.Errorf("id must be a number")
+	}
+	return parsed, nil
+}
+
+// paging applies defaults to page and limit arguments and rejects negative or too big ones.
+func paging(pageArg, limitArg *int32) (int, int, error) {
+	page, limit := intValue(pageArg), intValue(limitArg)
+	if page < 0 || limit < 0 {
+		return 0, 0, errors.New("page and limit must be positive numbers")
+	}
+	if limit > maxLimit {
+		return 0, 0, fmt.Errorf("limit must not exceed %d", maxLimit)
+	}
+
+	if page == 0 {
+		page = defaultPage
+	}
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	return page, limit, nil
+}
+
+func intValue(value *int32) int {
+	if value == nil {
+		return 0
+	}
+	return int(*value)
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
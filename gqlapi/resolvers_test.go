@@ -0,0 +1,58 @@
+package gqlapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPaging(t *testing.T) {
+	number := func(value int32) *int32 { return &value }
+
+	tests := []struct {
+		name		string
+		page		*int32
+		limit		*int32
+		wantPage	int
+		wantLimit	int
+		wantErr		bool
+	}{
+		{ name: "defaults", wantPage: 1, wantLimit: 10 },
+		{ name: "zero is default", page: number(0), limit: number(0), wantPage: 1, wantLimit: 10 },
+		{ name: "given", page: number(3), limit: number(25), wantPage: 3, wantLimit: 25 },
+		{ name: "max limit", limit: number(100), wantPage: 1, wantLimit: 100 },
+		{ name: "too big limit", limit: number(101), wantErr: true },
+		{ name: "negative page", page: number(-1), wantErr: true },
+		{ name: "negative limit", limit: number(-1), wantErr: true },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, limit, err := paging(tt.page, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("paging() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (page != tt.wantPage || limit != tt.wantLimit) {
+				t.Errorf("paging() = %d, %d, want %d, %d", page, limit, tt.wantPage, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	handler, err := NewHandler(&Resolver{}, nil)
+	if err != nil {
+		t.Fatalf("NewHandler() error = %v", err)
+	}
+
+	// every song { group { songs } } round adds two levels
+	deep := strings.Repeat("songs { group { ", 5) + "name" + strings.Repeat(" } }", 5)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql",
+		strings.NewReader(`{"query": "{ group(id: 1) { ` + deep + ` } }"}`)))
+
+	if !strings.Contains(recorder.Body.String(), "exceeds max depth") {
+		t.Errorf("deep query response = %s, want depth error", recorder.Body.String())
+	}
+}
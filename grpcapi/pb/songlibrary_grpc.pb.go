@@ -0,0 +1,324 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: songlibrary.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	SongLibrary_Get_FullMethodName        = "/songlibrary.v1.SongLibrary/Get"
+	SongLibrary_Search_FullMethodName     = "/songlibrary.v1.SongLibrary/Search"
+	SongLibrary_Add_FullMethodName        = "/songlibrary.v1.SongLibrary/Add"
+	SongLibrary_Update_FullMethodName     = "/songlibrary.v1.SongLibrary/Update"
+	SongLibrary_Delete_FullMethodName     = "/songlibrary.v1.SongLibrary/Delete"
+	SongLibrary_StreamText_FullMethodName = "/songlibrary.v1.SongLibrary/StreamText"
+)
+
+// SongLibraryClient is the client API for SongLibrary service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SongLibrary mirrors the REST API, it is served on GRPC_PORT.
+type SongLibraryClient interface {
+	Get(ctx context.Context, in *GetSongRequest, opts ...grpc.CallOption) (*Song, error)
+	Search(ctx context.Context, in *SongQuery, opts ...grpc.CallOption) (*SearchSongsResponse, error)
+	// Add looks the song up with info providers like POST /api/v1/songs/add.
+	Add(ctx context.Context, in *AddSongRequest, opts ...grpc.CallOption) (*Song, error)
+	Update(ctx context.Context, in *UpdateSongRequest, opts ...grpc.CallOption) (*Song, error)
+	Delete(ctx context.Context, in *DeleteSongRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// StreamText sends song text by units: couplets (default), lines or chars.
+	StreamText(ctx context.Context, in *StreamTextRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TextFragment], error)
+}
+
+type songLibraryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSongLibraryClient(cc grpc.ClientConnInterface) SongLibraryClient {
+	return &songLibraryClient{cc}
+}
+
+func (c *songLibraryClient) Get(ctx context.Context, in *GetSongRequest, opts ...grpc.CallOption) (*Song, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Song)
+	err := c.cc.Invoke(ctx, SongLibrary_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *songLibraryClient) Search(ctx context.Context, in *SongQuery, opts ...grpc.CallOption) (*SearchSongsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchSongsResponse)
+	err := c.cc.Invoke(ctx, SongLibrary_Search_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *songLibraryClient) Add(ctx context.Context, in *AddSongRequest, opts ...grpc.CallOption) (*Song, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Song)
+	err := c.cc.Invoke(ctx, SongLibrary_Add_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *songLibraryClient) Update(ctx context.Context, in *UpdateSongRequest, opts ...grpc.CallOption) (*Song, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Song)
+	err := c.cc.Invoke(ctx, SongLibrary_Update_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *songLibraryClient) Delete(ctx context.Context, in *DeleteSongRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, SongLibrary_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *songLibraryClient) StreamText(ctx context.Context, in *StreamTextRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[TextFragment], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SongLibrary_ServiceDesc.Streams[0], SongLibrary_StreamText_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamTextRequest, TextFragment]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SongLibrary_StreamTextClient = grpc.ServerStreamingClient[TextFragment]
+
+// SongLibraryServer is the server API for SongLibrary service.
+// All implementations must embed UnimplementedSongLibraryServer
+// for forward compatibility.
+//
+// SongLibrary mirrors the REST API, it is served on GRPC_PORT.
+type SongLibraryServer interface {
+	Get(context.Context, *GetSongRequest) (*Song, error)
+	Search(context.Context, *SongQuery) (*SearchSongsResponse, error)
+	// Add looks the song up with info providers like POST /api/v1/songs/add.
+	Add(context.Context, *AddSongRequest) (*Song, error)
+	Update(context.Context, *UpdateSongRequest) (*Song, error)
+	Delete(context.Context, *DeleteSongRequest) (*emptypb.Empty, error)
+	// StreamText sends song text by units: couplets (default), lines or chars.
+	StreamText(*StreamTextRequest, grpc.ServerStreamingServer[TextFragment]) error
+	mustEmbedUnimplementedSongLibraryServer()
+}
+
+// UnimplementedSongLibraryServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSongLibraryServer struct{}
+
+func (UnimplementedSongLibraryServer) Get(context.Context, *GetSongRequest) (*Song, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedSongLibraryServer) Search(context.Context, *SongQuery) (*SearchSongsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedSongLibraryServer) Add(context.Context, *AddSongRequest) (*Song, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Add not implemented")
+}
+func (UnimplementedSongLibraryServer) Update(context.Context, *UpdateSongRequest) (*Song, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
+}
+func (UnimplementedSongLibraryServer) Delete(context.Context, *DeleteSongRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedSongLibraryServer) StreamText(*StreamTextRequest, grpc.ServerStreamingServer[TextFragment]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamText not implemented")
+}
+func (UnimplementedSongLibraryServer) mustEmbedUnimplementedSongLibraryServer() {}
+func (UnimplementedSongLibraryServer) testEmbeddedByValue()                     {}
+
+// UnsafeSongLibraryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SongLibraryServer will
+// result in compilation errors.
+type UnsafeSongLibraryServer interface {
+	mustEmbedUnimplementedSongLibraryServer()
+}
+
+func RegisterSongLibraryServer(s grpc.ServiceRegistrar, srv SongLibraryServer) {
+	// If the following call pancis, it indicates UnimplementedSongLibraryServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SongLibrary_ServiceDesc, srv)
+}
+
+func _SongLibrary_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSongRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SongLibraryServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SongLibrary_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SongLibraryServer).Get(ctx, req.(*GetSongRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SongLibrary_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SongQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SongLibraryServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SongLibrary_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SongLibraryServer).Search(ctx, req.(*SongQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SongLibrary_Add_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddSongRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SongLibraryServer).Add(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SongLibrary_Add_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SongLibraryServer).Add(ctx, req.(*AddSongRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SongLibrary_Update_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSongRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SongLibraryServer).Update(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SongLibrary_Update_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SongLibraryServer).Update(ctx, req.(*UpdateSongRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SongLibrary_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSongRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SongLibraryServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SongLibrary_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SongLibraryServer).Delete(ctx, req.(*DeleteSongRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SongLibrary_StreamText_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTextRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SongLibraryServer).StreamText(m, &grpc.GenericServerStream[StreamTextRequest, TextFragment]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SongLibrary_StreamTextServer = grpc.ServerStreamingServer[TextFragment]
+
+// SongLibrary_ServiceDesc is the grpc.ServiceDesc for SongLibrary service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SongLibrary_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "songlibrary.v1.SongLibrary",
+	HandlerType: (*SongLibraryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _SongLibrary_Get_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _SongLibrary_Search_Handler,
+		},
+		{
+			MethodName: "Add",
+			Handler:    _SongLibrary_Add_Handler,
+		},
+		{
+			MethodName: "Update",
+			Handler:    _SongLibrary_Update_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _SongLibrary_Delete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamText",
+			Handler:       _SongLibrary_StreamText_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "songlibrary.proto",
+}
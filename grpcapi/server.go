@@ -0,0 +1,306 @@
+// Package grpcapi serves the SongLibrary gRPC service, pb is generated from proto/songlibrary.proto.
+package grpcapi
+
+//go:generate protoc -I ../proto --go_out=pb --go_opt=paths=source_relative --go-grpc_out=pb --go-grpc_opt=paths=source_relative songlibrary.proto
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"songsapi/enrichment"
+	"songsapi/grpcapi/pb"
+	"songsapi/infoapi"
+	"songsapi/logger"
+	"songsapi/lyrics"
+	"songsapi/query"
+	"songsapi/storage"
+
+	"github.com/lib/pq"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server implements SongLibrary with the same storages as REST handlers.
+type Server struct {
+	pb.UnimplementedSongLibraryServer
+
+	SongsTable 	storage.Storage[storage.Song]
+	GroupsTable storage.Storage[storage.Group]
+	InfoAPI		infoapi.SongInfoProvider
+}
+
+// NewGRPCServer registers the service and server reflection, so grpcurl can list methods.
+// Calls are traced, traceparent of the client is taken from metadata.
+func NewGRPCServer(server *Server) *grpc.Server {
+	grpcServer := grpc.NewServer(grpc.StatsHandler(otelgrpc.NewServerHandler()))
+	pb.RegisterSongLibraryServer(grpcServer, server)
+	reflection.Register(grpcServer)
+	return grpcServer
+}
+
+func (s *Server) Get(ctx context.Context, req *pb.GetSongRequest) (*pb.Song, error) {
+	song, err := s.SongsTable.Get(ctx, int(req.Id))
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	return s.withGroup(ctx, song)
+}
+
+func (s *Server) Search(ctx context.Context, req *pb.SongQuery) (*pb.SearchSongsResponse, error) {
+	songQuery := &query.SongQuery{
+		Name: req.Name,
+		Group: req.Group,
+		ReleaseDate: req.ReleaseDate,
+		Text: req.Text,
+		Link: req.Link,
+		Page: int(req.Page),
+		Limit: int(req.Limit),
+	}
+	if err := songQuery.Validate(); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// search over gRPC is always paged, so a single response stays bounded
+	if songQuery.Page == 0 {
+		songQuery.Page = 1
+	}
+	if songQuery.Limit == 0 {
+		songQuery.Limit = 10
+	}
+
+	response := &pb.SearchSongsResponse{ Page: int32(songQuery.Page), Limit: int32(songQuery.Limit), Songs: make([]*pb.Song, 0) }
+	songs, err := s.SongsTable.Find(ctx, songQuery)
+	if err == sql.ErrNoRows {
+		return response, nil
+	}
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	for _, song := range songs {
+		response.Songs = append(response.Songs, toProto(song))
+	}
+	return response, nil
+}
+
+func (s *Server) Add(ctx context.Context, req *pb.AddSongRequest) (*pb.Song, error) {
+	if strings.TrimSpace(req.Name) == "" || strings.TrimSpace(req.Group) == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and group are required")
+	}
+
+	newSong := storage.Song{ Name: req.Name, Group: req.Group }
+	info, err := s.InfoAPI.SongInfo(ctx, req.Group, req.Name)
+	if err != nil {
+		return nil, infoError(err)
+	}
+	if err := enrichment.ApplyInfo(&newSong, info); err != nil {
+		logger.Err.Println("can't parse data from info API - ", err)
+		return nil, status.Error(codes.Unavailable, "info API returned invalid data")
+	}
+
+	group, err := storage.ResolveGroup(ctx, s.GroupsTable, req.Group)
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	newSong.GroupId, newSong.Group = group.Id, group.Name
+	if err := s.SongsTable.Create(ctx, &newSong); err != nil {
+		return nil, storageError(err)
+	}
+
+	return toProto(&newSong), nil
+}
+
+// songFields copy fields of update_mask paths from the request to the stored song.
+var songFields = map[string]func(song *storage.Song, from *pb.Song){
+	"name": func(song *storage.Song, from *pb.Song) { song.Name = from.Name },
+	"group_id": func(song *storage.Song, from *pb.Song) { song.GroupId = int(from.GroupId) },
+	"release_date": func(song *storage.Song, from *pb.Song) { song.ReleaseDate = from.ReleaseDate },
+	"text": func(song *storage.Song, from *pb.Song) { song.Text = from.Text },
+	"link": func(song *storage.Song, from *pb.Song) { song.Link = from.Link },
+	"lang": func(song *storage.Song, from *pb.Song) { song.Lang = from.Lang },
+}
+
+// Update replaces fields listed in update_mask, without mask only non-empty fields of the request are taken.
+func (s *Server) Update(ctx context.Context, req *pb.UpdateSongRequest) (*pb.Song, error) {
+	if req.Song == nil || req.Song.Id == 0 {
+		return nil, status.Error(codes.InvalidArgument, "song with id is required")
+	}
+
+	paths := req.GetUpdateMask().GetPaths()
+	if len(paths) == 0 {
+		paths = setFields(req.Song)
+	}
+	if err := validateSong(req.Song, paths); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	song, err := s.SongsTable.Get(ctx, int(req.Song.Id))
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	for _, path := range paths {
+		songFields[path](song, req.Song)
+	}
+
+	if err := s.SongsTable.Update(ctx, song); err != nil {
+		return nil, storageError(err)
+	}
+
+	return s.Get(ctx, &pb.GetSongRequest{ Id: req.Song.Id })
+}
+
+func setFields(song *pb.Song) []string {
+	paths := make([]string, 0, len(songFields))
+	for path, value := range map[string]bool{
+		"name": song.Name != "",
+		"group_id": song.GroupId != 0,
+		"release_date": song.ReleaseDate != "",
+		"text": song.Text != "",
+		"link": song.Link != "",
+		"lang": song.Lang != "",
+	} {
+		if value {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// validateSong checks updated fields the way the storage can't: an empty name or
+// a date Postgres doesn't parse would otherwise fail as an internal error.
+func validateSong(song *pb.Song, paths []string) error {
+	for _, path := range paths {
+		switch path {
+		case "name":
+			if strings.TrimSpace(song.Name) == "" {
+				return errors.New("name can't be empty")
+			}
+		case "group_id":
+			if song.GroupId <= 0 {
+				return errors.New("group_id must be positive")
+			}
+		case "release_date":
+			if _, err := time.Parse("2006-01-02", song.ReleaseDate); song.ReleaseDate != "" && err != nil {
+				return errors.New("release_date must be yyyy-mm-dd")
+			}
+		case "link":
+			if link, err := url.Parse(song.Link); song.Link != "" && (err != nil || (link.Scheme != "http" && link.Scheme != "https") || link.Host == "") {
+				return errors.New("link must be an http or https URL")
+			}
+		case "text", "lang":
+		default:
+			return fmt.Errorf("unknown field %q in update_mask", path)
+		}
+	}
+	return nil
+}
+
+func (s *Server) Delete(ctx context.Context, req *pb.DeleteSongRequest) (*emptypb.Empty, error) {
+	song, err := s.SongsTable.Get(ctx, int(req.Id))
+	if err != nil {
+		return nil, storageError(err)
+	}
+
+	if err := s.SongsTable.Delete(ctx, song); err != nil {
+		return nil, storageError(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) StreamText(req *pb.StreamTextRequest, stream pb.SongLibrary_StreamTextServer) error {
+	unit, ok := lyrics.ParseUnit(req.Unit)
+	if !ok || unit == lyrics.SectionUnit {
+		return status.Error(codes.InvalidArgument, "unit must be one of line, couplet, char")
+	}
+
+	song, err := s.SongsTable.Get(stream.Context(), int(req.Id))
+	if err != nil {
+		return storageError(err)
+	}
+
+	for i, fragment := range lyrics.Split(song.Text, unit) {
+		if err := stream.Send(&pb.TextFragment{ Index: int32(i), Text: fragment }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withGroup fills the group name, Get of the storage returns only its id.
+func (s *Server) withGroup(ctx context.Context, song *storage.Song) (*pb.Song, error) {
+	if song.Group == "" && song.GroupId != 0 {
+		group, err := s.GroupsTable.Get(ctx, song.GroupId)
+		if err != nil {
+			return nil, storageError(err)
+		}
+		song.Group = group.Name
+	}
+	return toProto(song), nil
+}
+
+func toProto(song *storage.Song) *pb.Song {
+	converted := &pb.Song{
+		Id: int32(song.Id),
+		Name: song.Name,
+		Group: song.Group,
+		GroupId: int32(song.GroupId),
+		ReleaseDate: song.ReleaseDate,
+		Text: song.Text,
+		Link: song.Link,
+		Lang: song.Lang,
+		Status: song.Status,
+	}
+
+	// stored dates are read as 2006-01-02T00:00:00Z
+	if date, err := time.Parse(time.RFC3339, song.ReleaseDate); err == nil {
+		converted.ReleaseDate = date.Format("2006-01-02")
+	}
+	if song.UpdatedAt != nil {
+		converted.UpdatedAt = timestamppb.New(*song.UpdatedAt)
+	}
+	return converted
+}
+
+// storageError maps storage errors to codes, details of internal errors are only logged.
+func storageError(err error) error {
+	var dupErr *storage.DuplicateSongError
+	var pqErr *pq.Error
+	switch {
+	case err == sql.ErrNoRows:
+		return status.Error(codes.NotFound, "not found")
+	case errors.As(err, &dupErr):
+		return status.Error(codes.AlreadyExists, dupErr.Error())
+	case errors.As(err, &pqErr) && (pqErr.Code.Class() == "22" || pqErr.Code.Class() == "23"):
+		logger.Err.Println("grpc storage request rejected - ", err)
+		return status.Error(codes.InvalidArgument, "song data is invalid")
+	}
+
+	logger.Err.Println("grpc storage request failed - ", err)
+	return status.Error(codes.Internal, "storage request failed")
+}
+
+func infoError(err error) error {
+	switch {
+	case errors.Is(err, infoapi.ErrSongNotFound):
+		return status.Error(codes.NotFound, "song not found by info providers")
+	case errors.Is(err, infoapi.ErrTimeout):
+		return status.Error(codes.DeadlineExceeded, "info API timed out")
+	}
+
+	logger.Err.Println("grpc info API request failed - ", err)
+	return status.Error(codes.Unavailable, "info API is unavailable")
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"songsapi/logger"
+	"songsapi/storage"
+
+	"github.com/gorilla/mux"
+)
+
+type InfoCacheHandler struct {
+	Cache		storage.InfoCacheStorage
+	TTL			time.Duration
+}
+
+type InfoCacheEntry struct {
+	*storage.CachedInfo
+	ExpiresAt	*time.Time	`json:"expiresAt,omitempty"`
+	Expired		bool		`json:"expired"`
+}
+
+type InfoCacheResponse struct {
+	Entries		[]*InfoCacheEntry
+	Page		int
+	Limit		int
+}
+
+type InfoCacheInvalidateResponse struct {
+	Deleted		int64
+}
+
+func (h *InfoCacheHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+
+	case r.Method == http.MethodGet:
+		h.list(w, r)
+
+	case mux.Vars(r)["id"] != "":
+		h.delete(w, r)
+
+	default:
+		h.invalidate(w, r)
+	}
+}
+
+// @Tags admin
+// @Summary Lists cached info API responses
+// @Router /admin/info-cache [get]
+// @Security AdminToken
+// @Failure 401
+// @Produce json
+// @Param group query string false "Part of group name"
+// @Param song query string false "Part of song name"
+// @Param page query int false "Page number, starts from 1"
+// @Param limit query int false "Entries per page, default 50"
+// @Success 200 {object} InfoCacheResponse
+// @Failure 400
+// @Failure 500
+func (h *InfoCacheHandler) list(w http.ResponseWriter, r *http.Request) {
+	page, pageErr := ToInt(r.URL.Query().Get("page"))
+	limit, limitErr := ToInt(r.URL.Query().Get("limit"))
+	if pageErr != nil || limitErr != nil || page < 0 || limit < 0 {
+		logger.Err.Println("bad request query")
+		http.Error(w, "page and limit must be positive numbers", http.StatusBadRequest)
+		return
+	}
+
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = 50
+	}
+
+	cached, err := h.Cache.ListCachedInfo(r.Context(), r.URL.Query().Get("group"), r.URL.Query().Get("song"), limit, limit * (page - 1))
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	entries := make([]*InfoCacheEntry, 0, len(cached))
+	for _, info := range cached {
+		entry := &InfoCacheEntry{ CachedInfo: info }
+		if h.TTL > 0 {
+			expiresAt := info.FetchedAt.Add(h.TTL)
+			entry.ExpiresAt, entry.Expired = &expiresAt, time.Now().After(expiresAt)
+		}
+		entries = append(entries, entry)
+	}
+
+	Render(w, r, &InfoCacheResponse{ Entries: entries, Page: page, Limit: limit })
+}
+
+// @Tags admin
+// @Summary Invalidates cached info API responses of the group or its song
+// @Router /admin/info-cache [delete]
+// @Security AdminToken
+// @Failure 401
+// @Produce json
+// @Param group query string false "Group name"
+// @Param song query string false "Song name, requires group"
+// @Param all query bool false "Invalidate the whole cache"
+// @Success 200 {object} InfoCacheInvalidateResponse
+// @Failure 400
+// @Failure 500
+func (h *InfoCacheHandler) invalidate(w http.ResponseWriter, r *http.Request) {
+	group, song := r.URL.Query().Get("group"), r.URL.Query().Get("song")
+	all, _ := strconv.ParseBool(r.URL.Query().Get("all"))
+	if group == "" && (song != "" || !all) {
+		http.Error(w, "group is required, use all=true to invalidate the whole cache", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := h.Cache.InvalidateInfo(r.Context(), group, song)
+	if err != nil {
+		http.Error(w, "Can't invalidate info cache", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info.Printf("%d info cache entries invalidated\n", deleted)
+	Render(w, r, &InfoCacheInvalidateResponse{ Deleted: deleted })
+}
+
+// @Tags admin
+// @Summary Deletes cached info API response
+// @Router /admin/info-cache/{id} [delete]
+// @Security AdminToken
+// @Failure 401
+// @Param id path int true "Cache entry ID"
+// @Success 204
+// @Failure 404
+// @Failure 500
+func (h *InfoCacheHandler) delete(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if err := h.Cache.DeleteCachedInfo(r.Context(), id); err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
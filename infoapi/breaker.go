@@ -0,0 +1,75 @@
+package infoapi
+
+import (
+	"sync"
+	"time"
+)
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// CircuitBreaker stops calls to the info API after Threshold consecutive failures.
+// After Cooldown one trial call is let through: success closes the breaker, failure opens it again.
+type CircuitBreaker struct {
+	Threshold	int
+	Cooldown	time.Duration
+
+	mu			sync.Mutex
+	state		breakerState
+	failures	int
+	openedAt	time.Time
+}
+
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.Cooldown {
+			return false
+		}
+		b.state = stateHalfOpen
+		b.openedAt = time.Now()
+		return true
+	case stateHalfOpen:
+		// only one trial call at a time, unless the trial was abandoned without result
+		if time.Since(b.openedAt) < b.Cooldown {
+			return false
+		}
+		b.openedAt = time.Now()
+		return true
+	}
+	return true
+}
+
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = stateClosed
+	b.failures = 0
+}
+
+func (b *CircuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.Threshold {
+		b.state = stateOpen
+		b.openedAt = time.Now()
+	}
+}
+
+func (b *CircuitBreaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state != stateClosed && time.Since(b.openedAt) < b.Cooldown
+}
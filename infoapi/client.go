@@ -0,0 +1,247 @@
+package infoapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"songsapi/config"
+	"songsapi/logger"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("songsapi/infoapi")
+
+// SongInfo is the response of GET /info?group=&song=, ReleaseDate has dd.mm.yyyy format.
+type SongInfo struct {
+	ReleaseDate	string	`json:"releaseDate" yaml:"releaseDate"`
+	Text		string	`json:"text" yaml:"text"`
+	Link		string	`json:"link" yaml:"link"`
+}
+
+type Config struct {
+	BaseURL				string
+	Timeout				time.Duration
+	MaxRetries			int
+	Backoff				time.Duration
+	BreakerThreshold	int
+	BreakerCooldown		time.Duration
+	Providers			[]string
+	MergePolicy			string
+	FixturesDir			string
+	CacheTTL			time.Duration
+}
+
+type Client struct {
+	BaseURL		string
+	HTTPClient	*http.Client
+	MaxRetries	int
+	Backoff		time.Duration
+	Breaker		*CircuitBreaker
+}
+
+// maximum size of info API response body
+const maxResponseSize = 1 << 20
+
+// longer Retry-After of 429 responses is not waited for, the error is returned right away
+const maxRetryAfter = 10 * time.Second
+
+// ConfigFromEnv reads INFO_API_* variables, Debug_API_URL is still accepted for the base url.
+func ConfigFromEnv() Config {
+	baseURL := os.Getenv("INFO_API_URL")
+	if baseURL == "" {
+		baseURL = os.Getenv("Debug_API_URL")
+	}
+
+	return Config{
+		BaseURL: baseURL,
+		Timeout: config.Duration("INFO_API_TIMEOUT", 5 * time.Second),
+		MaxRetries: config.Int("INFO_API_RETRIES", 2),
+		Backoff: config.Duration("INFO_API_BACKOFF", 200 * time.Millisecond),
+		BreakerThreshold: config.Int("INFO_API_BREAKER_THRESHOLD", 5),
+		BreakerCooldown: config.Duration("INFO_API_BREAKER_COOLDOWN", 30 * time.Second),
+		Providers: config.List("INFO_PROVIDERS", []string{"cache", "http"}),
+		MergePolicy: config.String("INFO_MERGE_POLICY", MergeFirst),
+		FixturesDir: config.String("INFO_FIXTURES_DIR", "fixtures"),
+		CacheTTL: config.Duration("INFO_CACHE_TTL", 7 * 24 * time.Hour),
+	}
+}
+
+func NewClient(cfg Config) *Client {
+	return &Client{
+		BaseURL: cfg.BaseURL,
+		HTTPClient: &http.Client{ Timeout: cfg.Timeout, Transport: otelhttp.NewTransport(http.DefaultTransport) },
+		MaxRetries: cfg.MaxRetries,
+		Backoff: cfg.Backoff,
+		Breaker: &CircuitBreaker{ Threshold: cfg.BreakerThreshold, Cooldown: cfg.BreakerCooldown },
+	}
+}
+
+func (c *Client) Name() string {
+	return "http"
+}
+
+// SongInfo requests song details. Network errors, 5xx and 429 responses are retried with
+// exponential backoff or after Retry-After of 429, every failed call is counted by the circuit breaker.
+// Every attempt is a child span of the call and sends its traceparent to the info API.
+func (c *Client) SongInfo(ctx context.Context, group, song string) (*SongInfo, error) {
+	ctx, span := tracer.Start(ctx, "infoapi.SongInfo", trace.WithAttributes(
+		attribute.String("song.group", group), attribute.String("song.name", song)))
+	defer span.End()
+
+	info, err := c.songInfo(ctx, group, song)
+	if err != nil && err != ErrSongNotFound {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return info, err
+}
+
+func (c *Client) songInfo(ctx context.Context, group, song string) (*SongInfo, error) {
+	params := url.Values{}
+	params.Add("song", song)
+	params.Add("group", group)
+	fullURL := fmt.Sprintf("%s?%s", c.BaseURL, params.Encode())
+
+	var lastErr error
+	for attempt := 0; attempt <= c.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := c.wait(ctx, attempt, retryAfter(lastErr)); err != nil {
+				return nil, err
+			}
+		}
+
+		if !c.Breaker.Allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		info, retry, err := c.do(ctx, fullURL)
+		if err == nil {
+			c.Breaker.Success()
+			return info, nil
+		}
+
+		if ctx.Err() != nil {
+			// the caller has gone, it tells nothing about the info api health
+			return nil, contextError(ctx.Err())
+		}
+
+		lastErr = err
+		if !retry {
+			// 4xx and malformed responses are answers of a healthy service
+			c.Breaker.Success()
+			return nil, err
+		}
+
+		c.Breaker.Failure()
+		logger.Warn.Printf("info api request failed, attempt %d - %v\n", attempt + 1, err)
+		if retryAfter(err) > maxRetryAfter {
+			return nil, err
+		}
+	}
+
+	return nil, lastErr
+}
+
+func (c *Client) do(ctx context.Context, fullURL string) (*SongInfo, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if isTimeout(err) {
+			return nil, true, fmt.Errorf("%w: %v", ErrTimeout, err)
+		}
+		return nil, true, err
+	}
+
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, false, ErrSongNotFound
+	case resp.StatusCode == http.StatusGatewayTimeout:
+		return nil, true, fmt.Errorf("%w: %v", ErrTimeout, &StatusError{ StatusCode: resp.StatusCode })
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, true, &StatusError{ StatusCode: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")) }
+	case resp.StatusCode >= 500:
+		return nil, true, &StatusError{ StatusCode: resp.StatusCode }
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, &StatusError{ StatusCode: resp.StatusCode }
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		if isTimeout(err) {
+			return nil, true, fmt.Errorf("%w: %v", ErrTimeout, err)
+		}
+		return nil, true, err
+	}
+
+	info := SongInfo{}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return nil, false, &ResponseError{ Err: err }
+	}
+
+	return &info, false, nil
+}
+
+// wait sleeps before the retry, after is the delay asked by the info API if it is longer than the backoff.
+func (c *Client) wait(ctx context.Context, attempt int, after time.Duration) error {
+	delay := c.Backoff << (attempt - 1)
+	if delay > 0 {
+		delay += time.Duration(rand.Int63n(int64(delay) / 2 + 1))
+	}
+	delay = max(delay, after)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return contextError(ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
+
+func retryAfter(err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	return 0
+}
+
+// parseRetryAfter reads Retry-After header given in seconds or as HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds) * time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
+
+func contextError(err error) error {
+	if isTimeout(err) {
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	}
+	return err
+}
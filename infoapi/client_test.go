@@ -0,0 +1,266 @@
+package infoapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"songsapi/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.DoConsoleLog()
+	os.Exit(m.Run())
+}
+
+// upstream answers with the given statuses one by one and repeats the last one,
+// 200 is answered with a valid song info.
+type upstream struct {
+	statuses	[]int
+	header		http.Header
+	calls		atomic.Int32
+}
+
+func (u *upstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	call := int(u.calls.Add(1))
+	status := u.statuses[min(call, len(u.statuses)) - 1]
+	for name, values := range u.header {
+		w.Header()[name] = values
+	}
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		return
+	}
+	w.Write([]byte(`{"releaseDate": "16.07.2006", "text": "Ooh baby", "link": "https://example.com"}`))
+}
+
+func testClient(url string, retries int) *Client {
+	return NewClient(Config{
+		BaseURL: url,
+		Timeout: time.Second,
+		MaxRetries: retries,
+		Backoff: time.Millisecond,
+		BreakerThreshold: 100,
+		BreakerCooldown: time.Minute,
+	})
+}
+
+func TestSongInfoRetries(t *testing.T) {
+	tests := []struct {
+		name		string
+		statuses	[]int
+		wantErr		func(error) bool
+		wantCalls	int32
+	}{
+		{ "success", []int{ 200 }, nil, 1 },
+		{ "5xx is retried", []int{ 500, 503, 200 }, nil, 3 },
+		{ "5xx until retries end", []int{ 502 }, isStatus(502), 3 },
+		{ "504 is a timeout", []int{ 504 }, isError(ErrTimeout), 3 },
+		{ "429 is retried", []int{ 429, 200 }, nil, 2 },
+		{ "404 is not retried", []int{ 404, 200 }, isError(ErrSongNotFound), 1 },
+		{ "4xx is not retried", []int{ 400, 200 }, isStatus(400), 1 },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &upstream{ statuses: tt.statuses }
+			server := httptest.NewServer(u)
+			defer server.Close()
+
+			info, err := testClient(server.URL, 2).SongInfo(context.Background(), "Muse", "Supermassive Black Hole")
+			if tt.wantErr == nil && (err != nil || info.ReleaseDate != "16.07.2006") {
+				t.Errorf("SongInfo() = %v, %v, want song info", info, err)
+			}
+			if tt.wantErr != nil && !tt.wantErr(err) {
+				t.Errorf("SongInfo() error = %v", err)
+			}
+			if calls := u.calls.Load(); calls != tt.wantCalls {
+				t.Errorf("upstream calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestSongInfoMalformedResponse(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(`{"releaseDate": `))
+	}))
+	defer server.Close()
+
+	_, err := testClient(server.URL, 2).SongInfo(context.Background(), "Muse", "Uprising")
+	var responseErr *ResponseError
+	if !errors.As(err, &responseErr) {
+		t.Errorf("SongInfo() error = %v, want *ResponseError", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("upstream calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestSongInfoTimeout(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := testClient(server.URL, 1)
+	client.HTTPClient.Timeout = 20 * time.Millisecond
+
+	_, err := client.SongInfo(context.Background(), "Muse", "Uprising")
+	if !errors.Is(err, ErrTimeout) || HTTPStatus(err) != http.StatusGatewayTimeout {
+		t.Errorf("SongInfo() error = %v, want timeout", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("upstream calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestSongInfoRetryAfter(t *testing.T) {
+	tests := []struct {
+		name		string
+		retryAfter	string
+		wantErr		bool
+		wantCalls	int32
+		minElapsed	time.Duration
+	}{
+		{ "waits for retry after", "1", false, 2, time.Second },
+		{ "too long retry after is not waited for", "3600", true, 1, 0 },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := &upstream{ statuses: []int{ 429, 200 }, header: http.Header{ "Retry-After": { tt.retryAfter } } }
+			server := httptest.NewServer(u)
+			defer server.Close()
+
+			start := time.Now()
+			_, err := testClient(server.URL, 2).SongInfo(context.Background(), "Muse", "Uprising")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SongInfo() error = %v, want error %v", err, tt.wantErr)
+			}
+			if calls := u.calls.Load(); calls != tt.wantCalls {
+				t.Errorf("upstream calls = %d, want %d", calls, tt.wantCalls)
+			}
+			if elapsed := time.Since(start); elapsed < tt.minElapsed {
+				t.Errorf("retried after %v, want at least %v", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value	string
+		want	time.Duration
+	}{
+		{ "", 0 },
+		{ "3", 3 * time.Second },
+		{ "-3", 0 },
+		{ "soon", 0 },
+		{ time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0 },
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(date); got < 59 * time.Minute || got > time.Hour {
+		t.Errorf("parseRetryAfter(%q) = %v, want about an hour", date, got)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	u := &upstream{ statuses: []int{ 500 } }
+	server := httptest.NewServer(u)
+	defer server.Close()
+
+	client := testClient(server.URL, 0)
+	client.Breaker = &CircuitBreaker{ Threshold: 2, Cooldown: 50 * time.Millisecond }
+	call := func() error {
+		_, err := client.SongInfo(context.Background(), "Muse", "Uprising")
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := call(); !isStatus(500)(err) {
+			t.Fatalf("call %d error = %v, want status 500", i + 1, err)
+		}
+	}
+	if err := call(); !errors.Is(err, ErrCircuitOpen) || u.calls.Load() != 2 {
+		t.Fatalf("error after threshold = %v with %d upstream calls, want open breaker", err, u.calls.Load())
+	}
+
+	// a failed trial call opens the breaker again
+	time.Sleep(60 * time.Millisecond)
+	if err := call(); !isStatus(500)(err) || u.calls.Load() != 3 {
+		t.Fatalf("trial call error = %v with %d upstream calls, want status 500", err, u.calls.Load())
+	}
+	if err := call(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("error after failed trial = %v, want open breaker", err)
+	}
+
+	// a successful trial call closes it
+	time.Sleep(60 * time.Millisecond)
+	u.statuses = []int{ 200 }
+	u.calls.Store(0)
+	for i := 0; i < 2; i++ {
+		if err := call(); err != nil {
+			t.Fatalf("call %d after cooldown error = %v", i + 1, err)
+		}
+	}
+	if client.Breaker.Open() {
+		t.Error("breaker is open after successful calls")
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	breaker := &CircuitBreaker{ Threshold: 1, Cooldown: 30 * time.Millisecond }
+	breaker.Failure()
+	if breaker.Allow() || !breaker.Open() {
+		t.Fatal("breaker lets calls through right after opening")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	if !breaker.Allow() {
+		t.Fatal("breaker doesn't let a trial call through after cooldown")
+	}
+	if breaker.Allow() {
+		t.Error("breaker lets a second call through while the trial is running")
+	}
+
+	// the trial was abandoned without result, another one is allowed after cooldown
+	time.Sleep(40 * time.Millisecond)
+	if !breaker.Allow() {
+		t.Error("breaker doesn't let a new trial through after an abandoned one")
+	}
+
+	breaker.Success()
+	if !breaker.Allow() || !breaker.Allow() || breaker.Open() {
+		t.Error("breaker is not closed after successful trial")
+	}
+}
+
+func isStatus(status int) func(error) bool {
+	return func(err error) bool {
+		var statusErr *StatusError
+		return errors.As(err, &statusErr) && statusErr.StatusCode == status
+	}
+}
+
+func isError(target error) func(error) bool {
+	return func(err error) bool {
+		return errors.Is(err, target)
+	}
+}
@@ -0,0 +1,46 @@
+package infoapi
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"songsapi/storage"
+)
+
+// DBCache serves song details saved in the database from other providers,
+// entries older than TTL are treated as missing. Zero TTL means entries never expire.
+type DBCache struct {
+	Table	storage.InfoCacheStorage
+	TTL		time.Duration
+}
+
+// Expired reports whether the entry must be fetched again.
+func (c *DBCache) Expired(info *storage.CachedInfo) bool {
+	return c.TTL > 0 && time.Since(info.FetchedAt) > c.TTL
+}
+
+func (c *DBCache) Name() string {
+	return "cache"
+}
+
+func (c *DBCache) SongInfo(ctx context.Context, group, song string) (*SongInfo, error) {
+	cached, err := c.Table.CachedInfo(ctx, group, song)
+	if err == sql.ErrNoRows {
+		return nil, ErrSongNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Expired(cached) {
+		return nil, ErrSongNotFound
+	}
+
+	return &SongInfo{ ReleaseDate: cached.ReleaseDate, Text: cached.Text, Link: cached.Link }, nil
+}
+
+func (c *DBCache) Save(ctx context.Context, group, song string, info *SongInfo) error {
+	return c.Table.SaveInfo(ctx, &storage.CachedInfo{
+		Group: group, Song: song, ReleaseDate: info.ReleaseDate, Text: info.Text, Link: info.Link })
+}
@@ -0,0 +1,71 @@
+package infoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrCircuitOpen = errors.New("info api circuit breaker is open")
+	ErrSongNotFound = errors.New("info api doesn't know this song")
+	ErrTimeout = errors.New("info api request timed out")
+)
+
+// StatusError is returned when the info API answers with unexpected status code.
+// RetryAfter is the delay the info API asked for in 429 response.
+type StatusError struct {
+	StatusCode	int
+	RetryAfter	time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("info api responded with status %d", e.StatusCode)
+}
+
+// ResponseError is returned when the info API response can't be decoded.
+type ResponseError struct {
+	Err		error
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("invalid info api response - %v", e.Err)
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatus maps info API errors to the status code of our response:
+// 504 for timeouts, 404 for unknown songs and 502 for everything else.
+func HTTPStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, ErrSongNotFound):
+		return http.StatusNotFound
+	}
+	return http.StatusBadGateway
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// IsTemporary reports whether the request may succeed later: timeouts, open circuit,
+// 5xx responses and network failures are temporary, unknown songs and bad responses are not.
+func IsTemporary(err error) bool {
+	var statusErr *StatusError
+	var responseErr *ResponseError
+	switch {
+	case errors.Is(err, ErrSongNotFound), errors.As(err, &responseErr):
+		return false
+	case errors.As(err, &statusErr):
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
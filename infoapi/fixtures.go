@@ -0,0 +1,100 @@
+package infoapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FixtureProvider reads song details from Dir/<group>/<song>.json (.yaml or .yml).
+// Directory and file names are compared case and whitespace insensitive.
+type FixtureProvider struct {
+	Dir		string
+}
+
+func (p *FixtureProvider) Name() string {
+	return "fixtures"
+}
+
+func (p *FixtureProvider) SongInfo(ctx context.Context, group, song string) (*SongInfo, error) {
+	groupDir, err := findEntry(p.Dir, NormalizeGroup(group), true, NormalizeGroup)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := findEntry(groupDir, NormalizeTitle(song), false, func(name string) string {
+		return NormalizeTitle(strings.TrimSuffix(name, filepath.Ext(name)))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ReadFixture(file)
+}
+
+// ReadFixture decodes the song info file, the format is chosen by extension.
+func ReadFixture(path string) (*SongInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info := SongInfo{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &info)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &info)
+	default:
+		return nil, fmt.Errorf("unsupported fixture format %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't decode fixture %s - %w", path, err)
+	}
+
+	return &info, nil
+}
+
+func findEntry(dir, key string, isDir bool, normalize func(string) string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrSongNotFound
+		}
+		return "", err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() != isDir || (!isDir && !isFixtureFile(entry.Name())) {
+			continue
+		}
+		if normalize(entry.Name()) == key {
+			return filepath.Join(dir, entry.Name()), nil
+		}
+	}
+
+	return "", ErrSongNotFound
+}
+
+func isFixtureFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// NormalizeTitle matches normalize_title() of the database: lower case with collapsed whitespace.
+func NormalizeTitle(title string) string {
+	return strings.Join(strings.Fields(strings.ToLower(title)), " ")
+}
+
+// NormalizeGroup matches normalize_group_name() of the database, it also drops leading "the".
+func NormalizeGroup(name string) string {
+	return strings.TrimPrefix(NormalizeTitle(name), "the ")
+}
@@ -0,0 +1,145 @@
+package infoapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"songsapi/logger"
+)
+
+// MockConfig describes faults injected by MockServer, it can be changed at runtime.
+type MockConfig struct {
+	LatencyMs	int		`json:"latencyMs"`
+	JitterMs	int		`json:"jitterMs"`
+	ErrorRate	float64	`json:"errorRate"`
+	ErrorStatus	int		`json:"errorStatus"`
+}
+
+// MockServer serves GET /info?group=&song= like the real info API from fixtures.
+// GET and PUT /mock/config show and switch latency and error injection.
+type MockServer struct {
+	Fixtures	SongInfoProvider
+
+	mu			sync.RWMutex
+	config		MockConfig
+}
+
+func NewMockServer(fixtures SongInfoProvider, cfg MockConfig) *MockServer {
+	return &MockServer{ Fixtures: fixtures, config: cfg }
+}
+
+func (s *MockServer) Config() MockConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.config
+}
+
+func (s *MockServer) SetConfig(cfg MockConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.config = cfg
+}
+
+func (s *MockServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", s.info)
+	mux.HandleFunc("GET /mock/config", s.showConfig)
+	mux.HandleFunc("PUT /mock/config", s.updateConfig)
+	return mux
+}
+
+func (s *MockServer) info(w http.ResponseWriter, r *http.Request) {
+	group, song := r.URL.Query().Get("group"), r.URL.Query().Get("song")
+	logger.Info.Printf("mock info request: group=%q song=%q\n", group, song)
+
+	cfg := s.Config()
+	if delay := cfg.delay(); delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if cfg.ErrorRate > 0 && rand.Float64() < cfg.ErrorRate {
+		status := cfg.ErrorStatus
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		http.Error(w, "injected error", status)
+		return
+	}
+
+	if group == "" || song == "" {
+		http.Error(w, "group and song are required", http.StatusBadRequest)
+		return
+	}
+
+	info, err := s.Fixtures.SongInfo(r.Context(), group, song)
+	if errors.Is(err, ErrSongNotFound) {
+		http.Error(w, "song not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		logger.Err.Println("can't read fixture - ", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, info)
+}
+
+func (s *MockServer) showConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.Config())
+}
+
+func (s *MockServer) updateConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := s.Config()
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		http.Error(w, "can't parse config", http.StatusBadRequest)
+		return
+	}
+
+	if err := cfg.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.SetConfig(cfg)
+	logger.Info.Printf("mock info config changed: %+v\n", cfg)
+	writeJSON(w, cfg)
+}
+
+// Validate checks the config, ErrorStatus 0 means 500.
+func (c MockConfig) Validate() error {
+	if c.LatencyMs < 0 || c.JitterMs < 0 || c.ErrorRate < 0 || c.ErrorRate > 1 {
+		return errors.New("latency must be positive and error rate between 0 and 1")
+	}
+	// other codes would be taken by clients for a successful or redirected response
+	if c.ErrorStatus != 0 && (c.ErrorStatus < 400 || c.ErrorStatus > 599) {
+		return fmt.Errorf("error status must be between 400 and 599, got %d", c.ErrorStatus)
+	}
+	return nil
+}
+
+func (c MockConfig) delay() time.Duration {
+	delay := time.Duration(c.LatencyMs) * time.Millisecond
+	if c.JitterMs > 0 {
+		delay += time.Duration(rand.Intn(c.JitterMs + 1)) * time.Millisecond
+	}
+	return delay
+}
+
+func writeJSON(w http.ResponseWriter, object any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(object); err != nil {
+		logger.Err.Println("can't render JSON - ", err)
+	}
+}
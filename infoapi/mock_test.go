@@ -0,0 +1,43 @@
+package infoapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMockUpdateConfig(t *testing.T) {
+	tests := []struct {
+		name		string
+		body		string
+		wantStatus	int
+	}{
+		{ "valid", `{"latencyMs": 100, "errorRate": 0.5, "errorStatus": 503}`, http.StatusOK },
+		{ "default error status", `{"errorRate": 1, "errorStatus": 0}`, http.StatusOK },
+		{ "lowest error status", `{"errorStatus": 400}`, http.StatusOK },
+		{ "highest error status", `{"errorStatus": 599}`, http.StatusOK },
+		{ "success status", `{"errorStatus": 200}`, http.StatusBadRequest },
+		{ "redirect status", `{"errorStatus": 302}`, http.StatusBadRequest },
+		{ "unknown status", `{"errorStatus": 600}`, http.StatusBadRequest },
+		{ "negative latency", `{"latencyMs": -1}`, http.StatusBadRequest },
+		{ "error rate above 1", `{"errorRate": 1.5}`, http.StatusBadRequest },
+		{ "malformed", `{"errorStatus": `, http.StatusBadRequest },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := NewMockServer(nil, MockConfig{ ErrorStatus: http.StatusInternalServerError })
+			request := httptest.NewRequest(http.MethodPut, "/mock/config", strings.NewReader(tt.body))
+			recorder := httptest.NewRecorder()
+			server.Handler().ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", recorder.Code, tt.wantStatus, recorder.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK && server.Config().ErrorStatus != http.StatusInternalServerError {
+				t.Errorf("config changed by rejected request: %+v", server.Config())
+			}
+		})
+	}
+}
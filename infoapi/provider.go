@@ -0,0 +1,155 @@
+package infoapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"songsapi/logger"
+	"songsapi/storage"
+)
+
+const (
+	// MergeFirst returns the response of the first provider which knows the song.
+	MergeFirst = "first"
+	// MergeFill asks next providers only while some field is still missing.
+	MergeFill = "fill"
+)
+
+// SongInfoProvider is a source of song details, ErrSongNotFound means it doesn't know the song.
+type SongInfoProvider interface {
+	Name() string
+	SongInfo(ctx context.Context, group, song string) (*SongInfo, error)
+}
+
+// InfoStore is a provider which can keep responses of other providers.
+type InfoStore interface {
+	SongInfoProvider
+	Save(ctx context.Context, group, song string, info *SongInfo) error
+}
+
+// Chain asks providers in order and merges their responses according to the policy.
+type Chain struct {
+	Providers	[]SongInfoProvider
+	Policy		string
+}
+
+// NewChain builds providers listed in cfg.Providers, client is the "http" provider
+// and cache is used by the "cache" provider.
+func NewChain(cfg Config, client SongInfoProvider, cache storage.InfoCacheStorage) (*Chain, error) {
+	if cfg.MergePolicy != MergeFirst && cfg.MergePolicy != MergeFill {
+		return nil, fmt.Errorf("unknown info merge policy %q", cfg.MergePolicy)
+	}
+
+	chain := &Chain{ Policy: cfg.MergePolicy }
+	for _, name := range cfg.Providers {
+		switch name {
+		case "http":
+			chain.Providers = append(chain.Providers, client)
+		case "fixtures":
+			chain.Providers = append(chain.Providers, &FixtureProvider{ Dir: cfg.FixturesDir })
+		case "cache":
+			chain.Providers = append(chain.Providers, &DBCache{ Table: cache, TTL: cfg.CacheTTL })
+		default:
+			return nil, fmt.Errorf("unknown info provider %q", name)
+		}
+	}
+
+	if len(chain.Providers) == 0 {
+		return nil, errors.New("no info providers configured")
+	}
+
+	return chain, nil
+}
+
+func (c *Chain) Name() string {
+	names := make([]string, 0, len(c.Providers))
+	for _, provider := range c.Providers {
+		names = append(names, provider.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+// Cache returns the first database cache of the chain, nil if there is none.
+func (c *Chain) Cache() *DBCache {
+	for _, provider := range c.Providers {
+		if cache, ok := provider.(*DBCache); ok {
+			return cache
+		}
+	}
+	return nil
+}
+
+// SongInfo returns merged response of the providers. Failed providers are skipped, their
+// error is returned only if no provider knows the song. The result is saved into stores
+// which are placed before the last contributing provider.
+func (c *Chain) SongInfo(ctx context.Context, group, song string) (*SongInfo, error) {
+	var merged *SongInfo
+	var lastErr error
+	lastSource := -1
+
+	for i, provider := range c.Providers {
+		info, err := provider.SongInfo(ctx, group, song)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, contextError(ctx.Err())
+			}
+			if !errors.Is(err, ErrSongNotFound) {
+				logger.Warn.Printf("info provider %s failed - %v\n", provider.Name(), err)
+				lastErr = err
+			}
+			continue
+		}
+
+		if merged == nil {
+			merged = &SongInfo{}
+		}
+		if fillInfo(merged, info) {
+			lastSource = i
+		}
+
+		if c.Policy != MergeFill || merged.Complete() {
+			break
+		}
+	}
+
+	if merged == nil {
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, ErrSongNotFound
+	}
+
+	c.save(ctx, group, song, merged, lastSource)
+	return merged, nil
+}
+
+func (c *Chain) save(ctx context.Context, group, song string, info *SongInfo, before int) {
+	for _, provider := range c.Providers[:max(before, 0)] {
+		if store, ok := provider.(InfoStore); ok {
+			if err := store.Save(ctx, group, song, info); err != nil {
+				logger.Warn.Printf("can't save song info into %s - %v\n", store.Name(), err)
+			}
+		}
+	}
+}
+
+// Complete reports whether all fields are filled.
+func (i *SongInfo) Complete() bool {
+	return i.ReleaseDate != "" && i.Text != "" && i.Link != ""
+}
+
+// fillInfo copies fields missing in dst from src, it reports whether anything was copied.
+func fillInfo(dst, src *SongInfo) bool {
+	filled := false
+	for _, field := range []struct{ dst *string; src string }{
+		{ &dst.ReleaseDate, src.ReleaseDate }, { &dst.Text, src.Text }, { &dst.Link, src.Link },
+	} {
+		if *field.dst == "" && field.src != "" {
+			*field.dst = field.src
+			filled = true
+		}
+	}
+	return filled
+}
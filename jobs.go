@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"songsapi/logger"
+	"songsapi/storage"
+
+	"github.com/gorilla/mux"
+)
+
+type JobHandler struct {
+	Jobs		storage.JobStorage
+}
+
+type JobResponse struct {
+	JobId		int
+	SongId		int
+	Status		string
+}
+
+// WantsAsync reports whether the client asked to process the request in background.
+func WantsAsync(r *http.Request) bool {
+	if async, _ := strconv.ParseBool(r.URL.Query().Get("async")); async {
+		return true
+	}
+	return strings.Contains(strings.ToLower(r.Header.Get("Prefer")), "respond-async")
+}
+
+func (h *SongAddHandler) addAsync(w http.ResponseWriter, r *http.Request, newSong *storage.Song) {
+	foundGroup, err := storage.ResolveGroup(r.Context(), h.GroupsTable, newSong.Group)
+	if err != nil {
+		logger.Err.Println("group resolution failed - ", err)
+		http.Error(w, "Can't add group into database", http.StatusInternalServerError)
+		return
+	}
+
+	newSong.GroupId = foundGroup.Id
+	job, err := h.Enricher.Enqueue(r.Context(), newSong)
+	if HandleDuplicateSong(w, r, err) {
+		return
+	}
+	if err != nil {
+		logger.Err.Println("song creation failed - ", err)
+		http.Error(w, "Can't add new song into database", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/jobs/%d", job.Id))
+	RenderStatus(w, r, http.StatusAccepted, &JobResponse{ JobId: job.Id, SongId: newSong.Id, Status: job.Status })
+}
+
+// @Tags jobs
+// @Summary Returns background job state
+// @Router /jobs/{id} [get]
+// @Produce json
+// @Param id path int true "Job ID"
+// @Success 200 {object} storage.Job
+// @Failure 404
+// @Failure 500
+func (h *JobHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	jobId, _ := strconv.Atoi(mux.Vars(r)["id"])
+	job, err := h.Jobs.GetJob(r.Context(), jobId)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	Render(w, r, job)
+}
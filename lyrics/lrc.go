@@ -0,0 +1,148 @@
+package lyrics
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TimedLine is a single LRC line, Time is the moment in milliseconds from the start of the song.
+type TimedLine struct {
+	Time		int64	`json:"time"`
+	Line		string	`json:"line"`
+}
+
+// LRC is a parsed time-coded lyrics file. Tags keep ID tags like [ar:], [ti:] or [offset:],
+// the offset tag is already applied to the lines time.
+type LRC struct {
+	Tags		map[string]string	`json:"tags,omitempty"`
+	Lines		[]TimedLine			`json:"lines"`
+}
+
+type lrcTag struct {
+	Name		string	`xml:"Name,attr"`
+	Value		string	`xml:",chardata"`
+}
+
+// MarshalXML writes Tags as <Tag Name="..."> elements sorted by name, encoding/xml can't marshal maps.
+func (l LRC) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	names := make([]string, 0, len(l.Tags))
+	for name := range l.Tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tags := make([]lrcTag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, lrcTag{ Name: name, Value: l.Tags[name] })
+	}
+
+	return e.EncodeElement(struct {
+		Tags		[]lrcTag	`xml:"Tags>Tag,omitempty"`
+		Lines		[]TimedLine
+	}{ tags, l.Lines }, start)
+}
+
+type LRCError struct {
+	LineNumber	int
+	Reason		string
+}
+
+func (e *LRCError) Error() string {
+	return fmt.Sprintf("invalid lrc at line %d: %s", e.LineNumber, e.Reason)
+}
+
+var (
+	timestampRegex = regexp.MustCompile(`^\[(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	tagRegex = regexp.MustCompile(`^\[([a-zA-Z#]+):(.*)\]$`)
+)
+
+func ParseLRC(text string) (*LRC, error) {
+	lrc := &LRC{ Tags: make(map[string]string), Lines: make([]TimedLine, 0) }
+
+	for i, raw := range strings.Split(Normalize(text), "\n") {
+		lineNumber := i + 1
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+
+		if !timestampRegex.MatchString(line) {
+			tag := tagRegex.FindStringSubmatch(line)
+			if tag == nil {
+				return nil, &LRCError{ LineNumber: lineNumber, Reason: "line must start with [mm:ss.xx] timestamp or [tag:value]" }
+			}
+			lrc.Tags[strings.ToLower(tag[1])] = strings.TrimSpace(tag[2])
+			continue
+		}
+
+		times := make([]int64, 0, 1)
+		for {
+			match := timestampRegex.FindStringSubmatch(line)
+			if match == nil {
+				break
+			}
+			ms, err := timestampToMillis(match[1], match[2], match[3])
+			if err != nil {
+				return nil, &LRCError{ LineNumber: lineNumber, Reason: err.Error() }
+			}
+			times = append(times, ms)
+			line = line[len(match[0]):]
+		}
+
+		for _, ms := range times {
+			lrc.Lines = append(lrc.Lines, TimedLine{ Time: ms, Line: strings.TrimSpace(line) })
+		}
+	}
+
+	if len(lrc.Lines) == 0 {
+		return nil, &LRCError{ LineNumber: 0, Reason: "no timed lines found" }
+	}
+
+	if offset, ok := lrc.Tags["offset"]; ok {
+		// positive offset means lyrics should be shown earlier
+		shift, err := strconv.ParseInt(strings.TrimPrefix(offset, "+"), 10, 64)
+		if err != nil {
+			return nil, &LRCError{ LineNumber: 0, Reason: "offset tag must be an integer number of milliseconds" }
+		}
+		for i := range lrc.Lines {
+			lrc.Lines[i].Time = max(lrc.Lines[i].Time - shift, 0)
+		}
+	}
+
+	sort.SliceStable(lrc.Lines, func(i, j int) bool {
+		return lrc.Lines[i].Time < lrc.Lines[j].Time
+	})
+
+	return lrc, nil
+}
+
+func timestampToMillis(minutes, seconds, fraction string) (int64, error) {
+	mm, _ := strconv.ParseInt(minutes, 10, 64)
+	ss, _ := strconv.ParseInt(seconds, 10, 64)
+	if ss >= 60 {
+		return 0, fmt.Errorf("seconds must be less than 60, got %d", ss)
+	}
+
+	var ms int64
+	if fraction != "" {
+		ms, _ = strconv.ParseInt(fraction, 10, 64)
+		// .5 is 500ms, .05 is 50ms and .005 is 5ms
+		for i := len(fraction); i < 3; i++ {
+			ms *= 10
+		}
+	}
+
+	return (mm * 60 + ss) * 1000 + ms, nil
+}
+
+// LineAt returns index of the line which is active at the given playback moment,
+// -1 means that the first line hasn't started yet.
+func (l *LRC) LineAt(offset int64) int {
+	return sort.Search(len(l.Lines), func(i int) bool {
+		return l.Lines[i].Time > offset
+	}) - 1
+}
@@ -0,0 +1,109 @@
+package lyrics
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseLRC(t *testing.T) {
+	tests := []struct {
+		name		string
+		text		string
+		wantTags	map[string]string
+		wantLines	[]TimedLine
+	}{
+		{
+			name: "fractions",
+			text: "[00:01.5]half\n[00:02.05]two digits\n[00:03.123]three digits\n[01:04]no fraction\n[02:00:25]colon",
+			wantTags: map[string]string{},
+			wantLines: []TimedLine{
+				{ 1500, "half" }, { 2050, "two digits" }, { 3123, "three digits" }, { 64000, "no fraction" }, { 120250, "colon" },
+			},
+		},
+		{
+			name: "tags and repeated line",
+			text: "[ti: Song ]\n[AR:Group]\n\n[00:10.00][00:30.00]again\n[00:20.00] middle ",
+			wantTags: map[string]string{ "ti": "Song", "ar": "Group" },
+			wantLines: []TimedLine{ { 10000, "again" }, { 20000, "middle" }, { 30000, "again" } },
+		},
+		{
+			name: "positive offset shows lines earlier",
+			text: "[offset:+500]\n[00:00.20]first\n[00:01.00]second",
+			wantTags: map[string]string{ "offset": "+500" },
+			wantLines: []TimedLine{ { 0, "first" }, { 500, "second" } },
+		},
+		{
+			name: "negative offset shows lines later",
+			text: "[offset:-250]\n[00:01.00]first",
+			wantTags: map[string]string{ "offset": "-250" },
+			wantLines: []TimedLine{ { 1250, "first" } },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lrc, err := ParseLRC(tt.text)
+			if err != nil {
+				t.Fatalf("ParseLRC() error = %v", err)
+			}
+			if !reflect.DeepEqual(lrc.Tags, tt.wantTags) {
+				t.Errorf("tags = %v, want %v", lrc.Tags, tt.wantTags)
+			}
+			if !reflect.DeepEqual(lrc.Lines, tt.wantLines) {
+				t.Errorf("lines = %v, want %v", lrc.Lines, tt.wantLines)
+			}
+		})
+	}
+}
+
+func TestParseLRCErrors(t *testing.T) {
+	tests := []struct {
+		name		string
+		text		string
+		wantLine	int
+	}{
+		{ "seconds out of range", "[00:01.00]ok\n[00:60.00]too late", 2 },
+		{ "line without timestamp", "[00:01.00]ok\nplain text", 2 },
+		{ "too long fraction", "[00:01.0000]four digits", 1 },
+		{ "only tags", "[ti:Song]\n[ar:Group]", 0 },
+		{ "empty", "", 0 },
+		{ "bad offset", "[offset:soon]\n[00:01.00]first", 0 },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLRC(tt.text)
+			var lrcErr *LRCError
+			if !errors.As(err, &lrcErr) {
+				t.Fatalf("ParseLRC() error = %v, want *LRCError", err)
+			}
+			if lrcErr.LineNumber != tt.wantLine {
+				t.Errorf("line number = %d, want %d", lrcErr.LineNumber, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestLineAt(t *testing.T) {
+	lrc := &LRC{ Lines: []TimedLine{ { 1000, "first" }, { 2000, "second" }, { 2000, "same time" }, { 5000, "last" } } }
+
+	tests := []struct {
+		offset	int64
+		want	int
+	}{
+		{ 0, -1 },
+		{ 999, -1 },
+		{ 1000, 0 },
+		{ 1999, 0 },
+		{ 2000, 2 },
+		{ 4999, 2 },
+		{ 60000, 3 },
+	}
+
+	for _, tt := range tests {
+		if got := lrc.LineAt(tt.offset); got != tt.want {
+			t.Errorf("LineAt(%d) = %d, want %d", tt.offset, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package lyrics
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd = "</mark>"
+)
+
+// CoupletMatch is a couplet containing the searched text. Couplet is 0-based index of the couplet
+// as returned by song text pagination, Page is the page to request to get this couplet.
+type CoupletMatch struct {
+	Couplet		int			`json:"couplet"`
+	Page		int			`json:"page"`
+	Fragments	[]string	`json:"fragments"`
+}
+
+// SearchCouplets does case-insensitive search of q in every couplet. Fragments are the matched lines
+// as HTML: the lyrics are escaped and every occurrence is wrapped into highlight marks. limit is the
+// pagination limit used to calculate the page of the couplet, zero limit means all couplets on the first page.
+func SearchCouplets(couplets []string, q string, limit int) []CoupletMatch {
+	matches := make([]CoupletMatch, 0)
+	if strings.TrimSpace(q) == "" {
+		return matches
+	}
+
+	pattern := regexp.MustCompile(`(?i)` + regexp.QuoteMeta(q))
+
+	for i, couplet := range couplets {
+		fragments := make([]string, 0)
+		for _, line := range strings.Split(couplet, "\n") {
+			if found := pattern.FindAllStringIndex(line, -1); found != nil {
+				fragments = append(fragments, highlight(line, found))
+			}
+		}
+
+		if len(fragments) == 0 {
+			continue
+		}
+
+		page := 1
+		if limit > 0 {
+			page = i / limit + 1
+		}
+
+		matches = append(matches, CoupletMatch{ Couplet: i, Page: page, Fragments: fragments })
+	}
+
+	return matches
+}
+
+// highlight escapes the line and wraps the found ranges into highlight marks, so only the marks are markup.
+func highlight(line string, found [][]int) string {
+	var buf strings.Builder
+	end := 0
+	for _, match := range found {
+		buf.WriteString(html.EscapeString(line[end:match[0]]))
+		buf.WriteString(HighlightStart)
+		buf.WriteString(html.EscapeString(line[match[0]:match[1]]))
+		buf.WriteString(HighlightEnd)
+		end = match[1]
+	}
+	buf.WriteString(html.EscapeString(line[end:]))
+	return buf.String()
+}
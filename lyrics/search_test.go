@@ -0,0 +1,64 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearchCouplets(t *testing.T) {
+	couplets := Couplets("Hello darkness\nmy old friend\n\nI've come to talk\nwith you again\n\n" +
+		"<b>Rock & roll</b> is here\n\nhello, hello")
+
+	tests := []struct {
+		name	string
+		q		string
+		limit	int
+		want	[]CoupletMatch
+	}{
+		{
+			name: "case insensitive in several couplets",
+			q: "HELLO",
+			limit: 2,
+			want: []CoupletMatch{
+				{ Couplet: 0, Page: 1, Fragments: []string{ "<mark>Hello</mark> darkness" } },
+				{ Couplet: 3, Page: 2, Fragments: []string{ "<mark>hello</mark>, <mark>hello</mark>" } },
+			},
+		},
+		{
+			name: "every matched line of the couplet",
+			q: "o",
+			limit: 1,
+			want: []CoupletMatch{
+				{ Couplet: 0, Page: 1, Fragments: []string{ "Hell<mark>o</mark> darkness", "my <mark>o</mark>ld friend" } },
+				{ Couplet: 1, Page: 2, Fragments: []string{ "I&#39;ve c<mark>o</mark>me t<mark>o</mark> talk", "with y<mark>o</mark>u again" } },
+				{ Couplet: 2, Page: 3, Fragments: []string{ "&lt;b&gt;R<mark>o</mark>ck &amp; r<mark>o</mark>ll&lt;/b&gt; is here" } },
+				{ Couplet: 3, Page: 4, Fragments: []string{ "hell<mark>o</mark>, hell<mark>o</mark>" } },
+			},
+		},
+		{
+			name: "markup in lyrics and query is escaped",
+			q: "& roll</b>",
+			want: []CoupletMatch{
+				{ Couplet: 2, Page: 1, Fragments: []string{ "&lt;b&gt;Rock <mark>&amp; roll&lt;/b&gt;</mark> is here" } },
+			},
+		},
+		{
+			name: "regexp characters are literal",
+			q: "d.",
+			want: []CoupletMatch{},
+		},
+		{
+			name: "blank query",
+			q: "  ",
+			want: []CoupletMatch{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SearchCouplets(couplets, tt.q, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchCouplets(%q) =\n%+v\nwant\n%+v", tt.q, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package lyrics
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type SectionType string
+
+const (
+	Verse		SectionType = "verse"
+	Chorus		SectionType = "chorus"
+	PreChorus	SectionType = "pre-chorus"
+	Bridge		SectionType = "bridge"
+	Intro		SectionType = "intro"
+	Outro		SectionType = "outro"
+	Hook		SectionType = "hook"
+	Interlude	SectionType = "interlude"
+	Other		SectionType = "other"
+)
+
+// Section is a typed part of song lyrics. Index is 1-based position of the section
+// in the song, RepeatOf points to the first section with the same lines.
+type Section struct {
+	Index		int			`json:"index"`
+	Type		SectionType	`json:"type"`
+	Number		int			`json:"number,omitempty"`
+	Label		string		`json:"label,omitempty"`
+	Lines		[]string	`json:"lines"`
+	RepeatOf	int			`json:"repeatOf,omitempty"`
+}
+
+var (
+	markerRegex = regexp.MustCompile(`^\[([^\]]+)\]$`)
+	labelRegex = regexp.MustCompile(`(?i)^(verse|chorus|refrain|pre-?chorus|bridge|intro|outro|hook|interlude)\s*(\d+)?`)
+)
+
+var labelTypes = map[string]SectionType{
+	"verse": Verse,
+	"chorus": Chorus,
+	"refrain": Chorus,
+	"pre-chorus": PreChorus,
+	"prechorus": PreChorus,
+	"bridge": Bridge,
+	"intro": Intro,
+	"outro": Outro,
+	"hook": Hook,
+	"interlude": Interlude,
+}
+
+// ParseSections splits lyrics into sections. A section starts either with a marker
+// line like [Chorus] or [Verse 2], or after a blank line. Unmarked blocks are verses,
+// unless the same block occurs several times - then it is treated as a chorus.
+func ParseSections(text string) []Section {
+	sections := make([]Section, 0)
+	seenLabels := make(map[string]bool)
+	var current *Section
+
+	flush := func() {
+		if current != nil {
+			sections = append(sections, *current)
+			seenLabels[strings.ToLower(current.Label)] = true
+			current = nil
+		}
+	}
+
+	for _, line := range strings.Split(Normalize(text), "\n") {
+		line = strings.TrimSpace(line)
+
+		if marker := markerRegex.FindStringSubmatch(line); marker != nil {
+			flush()
+			current = newMarkedSection(marker[1])
+			continue
+		}
+
+		if line == "" {
+			// a bare marker of an already seen section is a repeat, otherwise its lines may follow after a blank line
+			if current != nil && (len(current.Lines) > 0 || seenLabels[strings.ToLower(current.Label)]) {
+				flush()
+			}
+			continue
+		}
+
+		if current == nil {
+			current = &Section{}
+		}
+		current.Lines = append(current.Lines, line)
+	}
+	flush()
+
+	detectRepeats(sections)
+	return sections
+}
+
+func newMarkedSection(label string) *Section {
+	section := &Section{ Label: label, Type: Other }
+	if match := labelRegex.FindStringSubmatch(label); match != nil {
+		section.Type = labelTypes[strings.ToLower(match[1])]
+		section.Number, _ = strconv.Atoi(match[2])
+	}
+	return section
+}
+
+func detectRepeats(sections []Section) {
+	firstByContent := make(map[string]int)
+	lastByLabel := make(map[string]int)
+	occurrences := make(map[string]int)
+
+	for i := range sections {
+		section := &sections[i]
+		section.Index = i + 1
+
+		// marker without lines, e.g. a bare [Chorus], repeats the previous section with this label
+		if len(section.Lines) == 0 {
+			if prev, ok := lastByLabel[strings.ToLower(section.Label)]; ok {
+				section.Lines = sections[prev].Lines
+			}
+		}
+
+		if section.Label != "" {
+			lastByLabel[strings.ToLower(section.Label)] = i
+		}
+
+		key := strings.ToLower(strings.Join(section.Lines, "\n"))
+		if key == "" {
+			continue
+		}
+
+		occurrences[key]++
+		if first, ok := firstByContent[key]; ok {
+			section.RepeatOf = sections[first].Index
+		} else {
+			firstByContent[key] = i
+		}
+	}
+
+	verses := 0
+	for i := range sections {
+		section := &sections[i]
+		if section.Label == "" {
+			section.Type = Verse
+			if occurrences[strings.ToLower(strings.Join(section.Lines, "\n"))] > 1 {
+				section.Type = Chorus
+			}
+		}
+
+		if section.Type == Verse && section.RepeatOf == 0 {
+			verses++
+			if section.Number == 0 {
+				section.Number = verses
+			}
+		}
+	}
+}
+
+// FilterSections selects sections by type name (e.g. "chorus") or by 1-based index.
+func FilterSections(sections []Section, selector string) []Section {
+	if index, err := strconv.Atoi(selector); err == nil {
+		if index < 1 || index > len(sections) {
+			return nil
+		}
+		return sections[index - 1:index]
+	}
+
+	wanted := strings.ToLower(selector)
+	if sectionType, ok := labelTypes[wanted]; ok {
+		wanted = string(sectionType)
+	}
+
+	filtered := make([]Section, 0)
+	for _, section := range sections {
+		if string(section.Type) == wanted {
+			filtered = append(filtered, section)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,94 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSections(t *testing.T) {
+	tests := []struct {
+		name	string
+		text	string
+		want	[]Section
+	}{
+		{
+			name: "markers",
+			text: "[Verse 1]\nfirst\nsecond\n\n[Chorus]\nsing\nalong\n\n[Verse 2]\nthird\n\n[Chorus]",
+			want: []Section{
+				{ Index: 1, Type: Verse, Number: 1, Label: "Verse 1", Lines: []string{ "first", "second" } },
+				{ Index: 2, Type: Chorus, Label: "Chorus", Lines: []string{ "sing", "along" } },
+				{ Index: 3, Type: Verse, Number: 2, Label: "Verse 2", Lines: []string{ "third" } },
+				{ Index: 4, Type: Chorus, Label: "Chorus", Lines: []string{ "sing", "along" }, RepeatOf: 2 },
+			},
+		},
+		{
+			name: "marker followed by blank line",
+			text: "[Intro]\n\nla la\n\n[Outro]\nbye",
+			want: []Section{
+				{ Index: 1, Type: Intro, Label: "Intro", Lines: []string{ "la la" } },
+				{ Index: 2, Type: Outro, Label: "Outro", Lines: []string{ "bye" } },
+			},
+		},
+		{
+			name: "label variants",
+			text: "[Pre-Chorus]\nup\n\n[Refrain]\nhey\n\n[Guitar solo]\n-",
+			want: []Section{
+				{ Index: 1, Type: PreChorus, Label: "Pre-Chorus", Lines: []string{ "up" } },
+				{ Index: 2, Type: Chorus, Label: "Refrain", Lines: []string{ "hey" } },
+				{ Index: 3, Type: Other, Label: "Guitar solo", Lines: []string{ "-" } },
+			},
+		},
+		{
+			name: "repeated unmarked block is a chorus",
+			text: "first\nsecond\n\nsing\nalong\n\nthird\n\n  Sing\nALONG  ",
+			want: []Section{
+				{ Index: 1, Type: Verse, Number: 1, Lines: []string{ "first", "second" } },
+				{ Index: 2, Type: Chorus, Lines: []string{ "sing", "along" } },
+				{ Index: 3, Type: Verse, Number: 2, Lines: []string{ "third" } },
+				{ Index: 4, Type: Chorus, Lines: []string{ "Sing", "ALONG" }, RepeatOf: 2 },
+			},
+		},
+		{
+			name: "empty text",
+			text: "\n\n",
+			want: []Section{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseSections(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSections() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterSections(t *testing.T) {
+	sections := ParseSections("[Verse]\na\n\n[Chorus]\nb\n\n[Verse]\nc\n\n[Chorus]")
+
+	tests := []struct {
+		selector	string
+		wantIndexes	[]int
+	}{
+		{ "chorus", []int{ 2, 4 } },
+		{ "Refrain", []int{ 2, 4 } },
+		{ "verse", []int{ 1, 3 } },
+		{ "bridge", []int{} },
+		{ "3", []int{ 3 } },
+		{ "0", []int{} },
+		{ "5", []int{} },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			indexes := make([]int, 0)
+			for _, section := range FilterSections(sections, tt.selector) {
+				indexes = append(indexes, section.Index)
+			}
+			if !reflect.DeepEqual(indexes, tt.wantIndexes) {
+				t.Errorf("FilterSections(%q) indexes = %v, want %v", tt.selector, indexes, tt.wantIndexes)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package lyrics
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Unit is a piece of song text used by text pagination.
+type Unit string
+
+const (
+	LineUnit	Unit = "line"
+	CoupletUnit	Unit = "couplet"
+	CharUnit	Unit = "char"
+	SectionUnit	Unit = "section"
+)
+
+var blankLinesRegex = regexp.MustCompile(`\n[ \t]*\n\s*`)
+
+func ParseUnit(s string) (Unit, bool) {
+	switch unit := Unit(strings.ToLower(s)); unit {
+	case "":
+		return CoupletUnit, true
+	case LineUnit, CoupletUnit, CharUnit, SectionUnit:
+		return unit, true
+	}
+	return "", false
+}
+
+// Split returns text units for pagination, sections aren't plain units and are split as couplets.
+func Split(text string, unit Unit) []string {
+	switch unit {
+	case LineUnit:
+		return Lines(text)
+	case CharUnit:
+		return Chars(text)
+	}
+	return Couplets(text)
+}
+
+// Normalize converts \r\n and \r line endings to \n.
+func Normalize(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n")
+}
+
+// Couplets splits song text by blank lines, empty couplets are skipped.
+func Couplets(text string) []string {
+	couplets := make([]string, 0)
+	for _, couplet := range blankLinesRegex.Split(Normalize(text), -1) {
+		if couplet = strings.TrimSpace(couplet); couplet != "" {
+			couplets = append(couplets, couplet)
+		}
+	}
+	return couplets
+}
+
+// Lines returns non-blank lines of song text.
+func Lines(text string) []string {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(Normalize(text), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Chars returns characters of song text with normalized line endings, so \r\n is one character.
+func Chars(text string) []string {
+	return strings.Split(Normalize(text), "")
+}
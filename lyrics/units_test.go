@@ -0,0 +1,110 @@
+package lyrics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseUnit(t *testing.T) {
+	tests := []struct {
+		s		string
+		want	Unit
+		wantOk	bool
+	}{
+		{ "", CoupletUnit, true },
+		{ "line", LineUnit, true },
+		{ "Couplet", CoupletUnit, true },
+		{ "CHAR", CharUnit, true },
+		{ "section", SectionUnit, true },
+		{ "word", "", false },
+	}
+
+	for _, tt := range tests {
+		if got, ok := ParseUnit(tt.s); got != tt.want || ok != tt.wantOk {
+			t.Errorf("ParseUnit(%q) = %q, %v, want %q, %v", tt.s, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
+
+func TestSplitUnits(t *testing.T) {
+	tests := []struct {
+		name			string
+		text			string
+		wantCouplets	[]string
+		wantLines		[]string
+		wantChars		[]string
+	}{
+		{
+			name: "unix line endings",
+			text: "a\nb\n\nc",
+			wantCouplets: []string{ "a\nb", "c" },
+			wantLines: []string{ "a", "b", "c" },
+			wantChars: []string{ "a", "\n", "b", "\n", "\n", "c" },
+		},
+		{
+			name: "windows line endings",
+			text: "a\r\nb\r\n\r\nc",
+			wantCouplets: []string{ "a\nb", "c" },
+			wantLines: []string{ "a", "b", "c" },
+			wantChars: []string{ "a", "\n", "b", "\n", "\n", "c" },
+		},
+		{
+			name: "old mac line endings",
+			text: "a\rb\r\rc",
+			wantCouplets: []string{ "a\nb", "c" },
+			wantLines: []string{ "a", "b", "c" },
+			wantChars: []string{ "a", "\n", "b", "\n", "\n", "c" },
+		},
+		{
+			name: "whitespace in blank lines and several of them",
+			text: "\n a\n \t\n\n\nb \n",
+			wantCouplets: []string{ "a", "b" },
+			wantLines: []string{ "a", "b" },
+			wantChars: []string{ "\n", " ", "a", "\n", " ", "\t", "\n", "\n", "\n", "b", " ", "\n" },
+		},
+		{
+			name: "no blank lines",
+			text: "a\nb\nc",
+			wantCouplets: []string{ "a\nb\nc" },
+			wantLines: []string{ "a", "b", "c" },
+			wantChars: []string{ "a", "\n", "b", "\n", "c" },
+		},
+		{
+			name: "multibyte characters",
+			text: "ёж",
+			wantCouplets: []string{ "ёж" },
+			wantLines: []string{ "ёж" },
+			wantChars: []string{ "ё", "ж" },
+		},
+		{
+			name: "empty",
+			text: "",
+			wantCouplets: []string{},
+			wantLines: []string{},
+			wantChars: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Couplets(tt.text); !reflect.DeepEqual(got, tt.wantCouplets) {
+				t.Errorf("Couplets() = %q, want %q", got, tt.wantCouplets)
+			}
+			if got := Lines(tt.text); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("Lines() = %q, want %q", got, tt.wantLines)
+			}
+			if got := Chars(tt.text); !reflect.DeepEqual(got, tt.wantChars) {
+				t.Errorf("Chars() = %q, want %q", got, tt.wantChars)
+			}
+		})
+	}
+}
+
+func TestParseSectionsLineEndings(t *testing.T) {
+	unix := ParseSections("[Verse]\na\nb\n\n[Chorus]\nc")
+	for _, text := range []string{ "[Verse]\r\na\r\nb\r\n\r\n[Chorus]\r\nc", "[Verse]\ra\rb\r\r[Chorus]\rc" } {
+		if got := ParseSections(text); !reflect.DeepEqual(got, unix) {
+			t.Errorf("ParseSections(%q) = %+v, want %+v", text, got, unix)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"songsapi/logger"
+	"songsapi/lyrics"
+	"songsapi/middleware"
+	"songsapi/query"
+	"songsapi/storage"
+
+	"github.com/gorilla/mux"
+)
+
+type SongTextSearchHandler struct {
+	SongsTable 	storage.Storage[storage.Song]
+}
+
+type LyricsSearchHandler struct {
+	SongsTable 	storage.Storage[storage.Song]
+	Changes		storage.ChangeMarker
+}
+
+type SongTextSearchResponse struct {
+	SongId		int
+	Query		string
+	Limit		int
+	Matches		[]lyrics.CoupletMatch
+}
+
+type SongLyricsMatches struct {
+	Song		storage.Song
+	Matches		[]lyrics.CoupletMatch
+}
+
+type LyricsSearchResponse struct {
+	Query			string
+	Songs			[]SongLyricsMatches
+	Page 			int
+	Limit 			int
+	CoupletLimit	int
+}
+
+// CSV has a row per matched couplet, fragments are joined with new lines.
+func (resp LyricsSearchResponse) CSVHeader() []string {
+	return []string{"id", "song", "group", "couplet", "page", "fragments"}
+}
+
+func (resp LyricsSearchResponse) CSVRows() [][]string {
+	rows := make([][]string, 0, len(resp.Songs))
+	for _, found := range resp.Songs {
+		for _, match := range found.Matches {
+			rows = append(rows, []string{ strconv.Itoa(found.Song.Id), found.Song.Name, found.Song.Group,
+				strconv.Itoa(match.Couplet), strconv.Itoa(match.Page), strings.Join(match.Fragments, "\n") })
+		}
+	}
+	return rows
+}
+
+// @Tags text pagination
+// @Summary Searches text inside the song lyrics
+// @Description Returns couplets containing the query with highlighted fragments and the page of text pagination to request.
+// @Description Fragments are HTML: lyrics are escaped and matches are wrapped into <mark> tags.
+// @Router /songs/{id}/text/search [get]
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param q query string true "Text to search"
+// @Param limit query int false "Couplets per page used to calculate the page of the match"
+// @Success 200 {object} SongTextSearchResponse
+// @Failure 400
+// @Failure 404
+// @Failure 500
+func (h *SongTextSearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+	limit, err := ToInt(r.URL.Query().Get("limit"))
+	if err != nil || limit < 0 || strings.TrimSpace(q) == "" {
+		logger.Err.Println("bad request query")
+		http.Error(w, "q param is required, limit must be a positive number", http.StatusBadRequest)
+		return
+	}
+
+	songId, _ := strconv.Atoi(mux.Vars(r)["id"])
+	song, err := h.SongsTable.Get(r.Context(), songId)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	Render(w, r, &SongTextSearchResponse{
+		SongId: song.Id,
+		Query: q,
+		Limit: limit,
+		Matches: lyrics.SearchCouplets(lyrics.Couplets(song.Text), q, limit),
+	})
+}
+
+// @Tags songs search
+// @Summary Searches text in lyrics of all songs
+// @Description Returns songs containing the query with matching couplets and their pages of text pagination.
+// @Description Fragments are HTML: lyrics are escaped and matches are wrapped into <mark> tags.
+// @Router /lyrics/search [get]
+// @Produce json,xml,text/csv,application/msgpack
+// @Param q query string true "Text to search"
+// @Param limit query int false "Maximum number of songs to return"
+// @Param page query int false "Page"
+// @Param coupletLimit query int false "Couplets per page used to calculate the page of the match"
+// @Success 200 {object} LyricsSearchResponse
+// @Failure 400
+// @Failure 404
+// @Failure 500
+func (h *LyricsSearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	q := params.Get("q")
+	page, pageErr := ToInt(params.Get("page"))
+	limit, limitErr := ToInt(params.Get("limit"))
+	coupletLimit, coupletLimitErr := ToInt(params.Get("coupletLimit"))
+	if pageErr != nil || limitErr != nil || coupletLimitErr != nil || page < 0 || limit < 0 || coupletLimit < 0 ||
+		strings.TrimSpace(q) == "" || strings.ContainsAny(q, "\r\n") {
+		logger.Err.Println("bad request query")
+		http.Error(w, "q param is required and must be one line, page, limit and coupletLimit must be positive numbers", 
+			http.StatusBadRequest)
+		return
+	}
+
+	// songs are matched line by line like couplets are highlighted, so every found song has matches
+	lastChange := LastChange(r.Context(), h.Changes)
+	foundSongs, err := h.SongsTable.Find(r.Context(), &query.SongQuery{ TextLine: q, Page: page, Limit: limit })
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	response := LyricsSearchResponse{
+		Query: q,
+		Songs: make([]SongLyricsMatches, 0, len(foundSongs)),
+		Page: page,
+		Limit: limit,
+		CoupletLimit: coupletLimit,
+	}
+
+	for _, song := range foundSongs {
+		matches := lyrics.SearchCouplets(lyrics.Couplets(song.Text), q, coupletLimit)
+		if len(matches) == 0 {
+			continue
+		}
+		song.Text = ""
+		response.Songs = append(response.Songs, SongLyricsMatches{ Song: *song, Matches: matches })
+	}
+
+	middleware.SetLastModified(w, lastChange)
+	Render(w, r, response)
+}
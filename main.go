@@ -1,17 +1,21 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"bytes"
 
 	_ "songsapi/docs"
+	"songsapi/changefeed"
+	"songsapi/config"
 
 	"github.com/lib/pq"
 
@@ -20,17 +24,28 @@ import (
 	"github.com/gorilla/schema"
 	"github.com/joho/godotenv"
 	httpSwagger "github.com/swaggo/http-swagger"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
 
 	"io"
 
+	"songsapi/enrichment"
+	"songsapi/gqlapi"
+	"songsapi/grpcapi"
+	"songsapi/infoapi"
 	"songsapi/logger"
+	"songsapi/metrics"
+	"songsapi/lyrics"
 	"songsapi/middleware"
+	"songsapi/outbox"
 	"songsapi/query"
+	"songsapi/render"
 	"songsapi/storage"
-
-	"time"
+	"songsapi/tracing"
+	"songsapi/webhooks"
 
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 // @title Songs Library API
@@ -38,6 +53,10 @@ import (
 // @description This is a project implementing a service, providing songs information.
 // @host localhost:8080
 // @BasePath /api/v1
+// @securityDefinitions.apikey AdminToken
+// @in header
+// @name Authorization
+// @description "Bearer <ADMIN_TOKEN>", required by /admin routes
 
 // @contact.email nickita-ananiev@yandex.ru
 
@@ -48,22 +67,53 @@ type SongResponse struct {
 	Limit 		int
 }
 
+func (resp SongResponse) CSVHeader() []string {
+	return []string{"id", "song", "group", "releaseDate", "link", "lang", "status", "text"}
+}
+
+func (resp SongResponse) CSVRows() [][]string {
+	rows := make([][]string, 0, len(resp.Songs))
+	for _, song := range resp.Songs {
+		rows = append(rows, []string{
+			strconv.Itoa(song.Id), song.Name, song.Group, song.ReleaseDate, song.Link, song.Lang, song.Status, song.Text })
+	}
+	return rows
+}
+
+// SongTextResponse contains a page of song text. Fragments are the units of the page, 
+// for char unit it is a single string. Couplets duplicate Fragments for couplet unit.
 type SongTextResponse struct {
+	Unit		lyrics.Unit
+	Fragments	[]string
 	Couplets	[]string
+	Lang		string
 	Page 		int
 	Limit 		int
+	Total		int
+}
+
+type SongSectionsResponse struct {
+	Unit		lyrics.Unit
+	Sections	[]lyrics.Section
+	Lang		string
+	Page 		int
+	Limit 		int
+	Total		int
 }
 
 type SongSearchHandler struct {
 	SongsTable 	storage.Storage[storage.Song]
+	Changes		storage.ChangeMarker
 }
 
 type SongOperationsHandler struct {
-	SongsTable 	storage.Storage[storage.Song]
+	SongsTable 		storage.Storage[storage.Song]
+	Translations	storage.TranslationStorage
 }
 
 type TextPaginationHandler struct {
-	Song		*storage.Song
+	Song			*storage.Song
+	Translations	storage.TranslationStorage
 }
 
 type SongDeleteHandler struct {
@@ -79,7 +129,46 @@ type SongUpdateHandler struct {
 type SongAddHandler struct {
 	SongsTable 	storage.Storage[storage.Song]
 	GroupsTable storage.Storage[storage.Group]
-	DebugApiURL	string
+	InfoAPI		infoapi.SongInfoProvider
+	Enricher	*enrichment.Enricher
+}
+
+type SongDuplicatesHandler struct {
+	SongsTable 	storage.DuplicateFinder
+}
+
+type SongDuplicatesResponse struct {
+	Duplicates	[]*storage.DuplicatePair
+	Similarity	float64
+	Page		int
+	Limit		int
+}
+
+type SongMergeHandler struct {
+	SongsTable 	storage.Merger[storage.Song]
+}
+
+type GroupMergeHandler struct {
+	GroupsTable	storage.Merger[storage.Group]
+	SongsCache	storage.Purger
+}
+
+type GroupAliasesHandler struct {
+	GroupsTable	storage.AliasStorage
+	SongsCache	storage.Purger
+}
+
+type GroupAliasesResponse struct {
+	Aliases		[]*storage.GroupAlias
+}
+
+type MergeRequest struct {
+	SourceId	int		`json:"sourceId"`
+}
+
+type SongConflictResponse struct {
+	Error		string
+	ExistingId	int
 }
 
 type SongAddRequest struct {
@@ -91,7 +180,7 @@ type SongAddRequest struct {
 // @Summary Returns a songs search result 
 // @Description This endpoint parses url query params and do SQL select request based on them.
 // @Tags songs search
-// @Produce json
+// @Produce json,xml,text/csv,application/msgpack
 // @Router /songs [get]
 // @Param limit query int false "Maximum number of songs to return"
 // @Param page query int false "Page"
@@ -119,13 +208,16 @@ func (h *SongSearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			for _, field := range errList {
 				fmt.Fprintf(&buf, "Validation error in field %v\n", field)
 			} 
+		} else {
+			buf.WriteString(err.Error())
 		}
 		logger.Err.Println("query params didn't pass validation")
 		http.Error(w, buf.String(), http.StatusBadRequest)
 		return
 	}
 
-	foundSongs, err := h.SongsTable.Find(songQuery)
+	lastChange := LastChange(r.Context(), h.Changes)
+	foundSongs, err := h.SongsTable.Find(r.Context(), songQuery)
 	if err != nil {
 		HandleDBSearchFail(w, err)
 		return
@@ -141,7 +233,8 @@ func (h *SongSearchHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		response.Songs[i] = *song
 	}
 	
-	RenderJSON(w, response)
+	middleware.SetLastModified(w, lastChange)
+	Render(w, r, response)
 }
 
 
@@ -162,7 +255,7 @@ func (h *SongOperationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		return
 	}
 	songId, _ := strconv.Atoi(id)
-	foundSong, err := h.SongsTable.Get(songId)
+	foundSong, err := h.SongsTable.Get(r.Context(), songId)
 	if err != nil {
 		HandleDBSearchFail(w, err)
 		return
@@ -171,12 +264,13 @@ func (h *SongOperationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 	switch r.Method {
 
 	case http.MethodGet:
+		SetSongsLastModified(w, foundSong)
 		if strings.HasSuffix(r.URL.Path, "/text") {
-			textHandler := &TextPaginationHandler{ Song: foundSong }
+			textHandler := &TextPaginationHandler{ Song: foundSong, Translations: h.Translations }
 			textHandler.ServeHTTP(w, r)
 			return
 		}
-		RenderJSON(w, *foundSong)
+		Render(w, r, *foundSong)
 
 	case http.MethodDelete:
 		deleteHandler := &SongDeleteHandler{ Song: foundSong, SongsTable: h.SongsTable }
@@ -199,7 +293,7 @@ func (h *SongOperationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 // @Failure 404
 // @Failure 500 
 func (h *SongDeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	err := h.SongsTable.Delete(h.Song)
+	err := h.SongsTable.Delete(r.Context(), h.Song)
 	if err != nil {
 		logger.Err.Println("delete failed - ", err)
 		http.Error(w, fmt.Sprintf("Can't delete song with id = %d, Error: %v", h.Song.Id, err), http.StatusInternalServerError)
@@ -217,12 +311,20 @@ func (h *SongDeleteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // @Success 202
 // @Failure 400
 // @Failure 404
+// @Failure 409 {object} SongConflictResponse
 // @Failure 500 
 func (h *SongUpdateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var updatedSong storage.Song
-	PasreJSON(r.Body, &updatedSong)
+	if err := PasreJSON(r.Body, &updatedSong); err != nil {
+		logger.Err.Println("bad request body - ", err)
+		http.Error(w, "Can't parse request body", http.StatusBadRequest)
+		return
+	}
 
-	err := h.SongsTable.Update(&updatedSong)
+	err := h.SongsTable.Update(r.Context(), &updatedSong)
+	if HandleDuplicateSong(w, r, err) {
+		return
+	}
 	if err != nil {
 		logger.Err.Println("update failed - ", err)
 		http.Error(w, fmt.Sprintf("Can't update song with id = %d, Error: %v", h.SongId, err), http.StatusInternalServerError)
@@ -235,105 +337,161 @@ func (h *SongUpdateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // @Tags text pagination
 // @Summary Returns song text fragment
-// @Description Does search for the song in database, then splits its text to couplets
+// @Description Does search for the song in database, then splits its text to pages of the given unit:
+// @Description couplets separated by blank lines (default), non-blank lines, characters or typed sections (verse, chorus, bridge...)
 // @Router /songs/{id}/text [get]
 // @Param id path int true "Song ID"
-// @Param limit query int false "Maximum number of couplets to return"
+// @Param unit query string false "Pagination unit" Enums(couplet, line, char, section)
+// @Param limit query int false "Maximum number of units to return"
 // @Param page query int false "Page"
+// @Param structured query bool false "Same as unit=section"
+// @Param section query string false "Section type (e.g. chorus) or 1-based section number, implies unit=section"
+// @Param lang query string false "Language of lyrics, original text is returned if there is no such translation"
 // @Success 200 {object} SongTextResponse
+// @Success 200 {object} SongSectionsResponse
 // @Failure 400
 // @Failure 404
 // @Failure 500
 func (h *TextPaginationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	page, pageErr := ToInt(r.URL.Query().Get("page"))
 	limit, limitErr := ToInt(r.URL.Query().Get("limit"))
-	if pageErr != nil || limitErr != nil {
+	if pageErr != nil || limitErr != nil || page < 0 || limit < 0 {
 		logger.Err.Println("bad request query")
-		http.Error(w, "Can't parse query", http.StatusInternalServerError)
+		http.Error(w, "page and limit must be positive numbers", http.StatusBadRequest)
 		return
 	}
-	couplets := strings.Split(h.Song.Text, "\n\n")
 
-	if page == 0 {
-		page = 1
+	unit, ok := lyrics.ParseUnit(r.URL.Query().Get("unit"))
+	if !ok {
+		logger.Err.Println("bad request query")
+		http.Error(w, "unit must be one of line, couplet, char, section", http.StatusBadRequest)
+		return
 	}
 
-	if limit == 0 {
-		limit = len(couplets)
+	text, lang, err := SongText(r.Context(), h.Song, h.Translations, r.URL.Query().Get("lang"))
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
 	}
 
-	offset := limit * (page - 1)
-	end := min(offset + limit, len(couplets))
-	if offset >= len(couplets) {
+	if lang != "" {
+		w.Header().Set("Content-Language", lang)
+	}
+
+	structured, _ := strconv.ParseBool(r.URL.Query().Get("structured"))
+	selector := r.URL.Query().Get("section")
+	if unit == lyrics.SectionUnit || structured || selector != "" {
+		serveSections(w, r, text, lang, selector, page, limit)
+		return
+	}
+
+	units := lyrics.Split(text, unit)
+	fragment, page, limit, ok := Paginate(units, page, limit)
+	if !ok {
 		logger.Err.Println("lyrics not found")
-		http.Error(w, "Couplets not found", http.StatusNotFound)
+		http.Error(w, "Text fragment not found", http.StatusNotFound)
+		return
+	}
+
+	response := &SongTextResponse{
+		Unit: unit,
+		Fragments: fragment,
+		Lang: lang,
+		Page: page,
+		Limit: limit,
+		Total: len(units),
+	}
+
+	switch unit {
+	case lyrics.CoupletUnit:
+		response.Couplets = fragment
+	case lyrics.CharUnit:
+		response.Fragments = []string{ strings.Join(fragment, "") }
+	}
+
+	Render(w, r, response)
+}
+
+func serveSections(w http.ResponseWriter, r *http.Request, text, lang, selector string, page, limit int) {
+	sections := lyrics.ParseSections(text)
+	if selector != "" {
+		sections = lyrics.FilterSections(sections, selector)
+	}
+
+	fragment, page, limit, ok := Paginate(sections, page, limit)
+	if !ok {
+		logger.Err.Println("lyrics sections not found")
+		http.Error(w, "Sections not found", http.StatusNotFound)
 		return
 	}
-	RenderJSON(w, &SongTextResponse{
-		Couplets: couplets[offset:end],
+	Render(w, r, &SongSectionsResponse{
+		Unit: lyrics.SectionUnit,
+		Sections: fragment,
+		Lang: lang,
 		Page: page,
 		Limit: limit,
+		Total: len(sections),
 	})
 }
 
 // @Tags songs operations
 // @Summary Adds new song
+// @Description Song details are requested from the info API. With async=true or "Prefer: respond-async" header 
+// @Description the song is saved right away as pending_enrichment and details are requested in background
 // @Router /songs/add [post]
 // @Param request body SongAddRequest true "Song creation request"
+// @Param async query bool false "Enrich the song in background"
 // @Success 201 
+// @Success 202 {object} JobResponse
+// @Failure 400
 // @Failure 404
+// @Failure 409 {object} SongConflictResponse
 // @Failure 500 
+// @Failure 502
+// @Failure 504
 func (h *SongAddHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var newSong storage.Song
-	PasreJSON(r.Body, &newSong)
 	defer r.Body.Close()
-	
-	params := url.Values{}
-	params.Add("song", newSong.Name)
-	params.Add("group", newSong.Group)
-
-	fullURL := fmt.Sprintf("%s?%s", h.DebugApiURL, params.Encode())
+	if err := PasreJSON(r.Body, &newSong); err != nil {
+		logger.Err.Println("bad request body - ", err)
+		http.Error(w, "Can't parse request body", http.StatusBadRequest)
+		return
+	}
 
-	resp, err := http.Get(fullURL)
-	if err != nil {
-		logger.Err.Println("request to info api failed - ", err)
-		http.Error(w, "Error during info api request", http.StatusInternalServerError)
+	if strings.TrimSpace(newSong.Name) == "" || strings.TrimSpace(newSong.Group) == "" {
+		http.Error(w, "song and group are required", http.StatusBadRequest)
 		return
 	}
 
-	defer resp.Body.Close()
-	
-	PasreJSON(resp.Body, &newSong)
+	if WantsAsync(r) {
+		h.addAsync(w, r, &newSong)
+		return
+	}
 
-	parsedDate, err := time.Parse("02.01.2006", newSong.ReleaseDate)
+	info, err := h.InfoAPI.SongInfo(r.Context(), newSong.Group, newSong.Name)
 	if err != nil {
-		logger.Err.Println("can't parse release date - ", err)
-		http.Error(w, "Can't parse data from info API", http.StatusInternalServerError)
+		HandleInfoAPIFail(w, err)
 		return
 	}
 
-	newSong.ReleaseDate = parsedDate.Format("2006-01-02")
-
-	err = h.GroupsTable.Create(&storage.Group{ Name: newSong.Group })
-	if err != nil {
-		pgErr, ok := err.(*pq.Error)
-		if !(ok && pgErr.Code == "23505") {
-			logger.Err.Println("group creation failed - ", err)
-			http.Error(w, "Can't add group into database", http.StatusInternalServerError)
-			return
-		}
+	if err := enrichment.ApplyInfo(&newSong, info); err != nil {
+		logger.Err.Println("bad info api data - ", err)
+		http.Error(w, "Can't parse data from info API", http.StatusBadGateway)
+		return
 	}
 
-	groups, err := h.GroupsTable.Find(&query.GroupQuery{ Name: newSong.Group })
+	foundGroup, err := storage.ResolveGroup(r.Context(), h.GroupsTable, newSong.Group)
 	if err != nil {
-		logger.Err.Println("group search failed - ", err)
-		http.Error(w, "Can't add new song due to database error", http.StatusInternalServerError)
+		logger.Err.Println("group resolution failed - ", err)
+		http.Error(w, "Can't add group into database", http.StatusInternalServerError)
 		return
 	}
 
-	foundGroup := groups[0]
 	newSong.GroupId = foundGroup.Id
-	err = h.SongsTable.Create(&newSong)
+	err = h.SongsTable.Create(r.Context(), &newSong)
+	if HandleDuplicateSong(w, r, err) {
+		return
+	}
 	if err != nil {
 		logger.Err.Println("song creation failed - ", err)
 		http.Error(w, "Can't add new song into database", http.StatusInternalServerError)
@@ -344,6 +502,236 @@ func (h *SongAddHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Song succesfully added"))
 }
 
+// @Tags songs search
+// @Summary Returns probable duplicates
+// @Description Lists pairs of songs with similar names inside one group or with identical lyrics
+// @Produce json
+// @Router /songs/duplicates [get]
+// @Param similarity query number false "Minimum name similarity from 0 to 1, default 0.85"
+// @Param page query int false "Page, default 1"
+// @Param limit query int false "Pairs per page, default 10"
+// @Success 200 {object} SongDuplicatesResponse
+// @Failure 400
+// @Failure 500
+func (h *SongDuplicatesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	similarity := 0.85
+	if param := r.URL.Query().Get("similarity"); param != "" {
+		parsed, err := strconv.ParseFloat(param, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			logger.Err.Println("bad request query")
+			http.Error(w, "similarity must be a number between 0 and 1", http.StatusBadRequest)
+			return
+		}
+		similarity = parsed
+	}
+
+	page, pageErr := ToInt(r.URL.Query().Get("page"))
+	limit, limitErr := ToInt(r.URL.Query().Get("limit"))
+	if pageErr != nil || limitErr != nil || page < 0 || limit < 0 {
+		logger.Err.Println("bad request query")
+		http.Error(w, "page and limit must be positive numbers", http.StatusBadRequest)
+		return
+	}
+	if page == 0 {
+		page = 1
+	}
+	if limit == 0 {
+		limit = 10
+	}
+
+	duplicates, err := h.SongsTable.FindDuplicates(r.Context(), similarity, page, limit)
+	if err != nil {
+		logger.Err.Println("duplicates search failed - ", err)
+		http.Error(w, fmt.Sprintf("Search failed Error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	Render(w, r, &SongDuplicatesResponse{
+		Duplicates: duplicates,
+		Similarity: similarity,
+		Page: page,
+		Limit: limit,
+	})
+}
+
+// @Tags songs operations
+// @Summary Merges duplicate song into the song with given Id
+// @Description Missing text and link of the target song are taken from the source one, then the source song is deleted
+// @Router /songs/{id}/merge [post]
+// @Param id path int true "Target song ID"
+// @Param request body MergeRequest true "Song to merge"
+// @Success 200 {object} storage.Song
+// @Failure 400
+// @Failure 404
+// @Failure 500
+func (h *SongMergeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveMerge(w, r, h.SongsTable, nil)
+}
+
+// @Tags groups operations
+// @Summary Merges duplicate group into the group with given Id
+// @Description All songs of the source group are moved to the target group, source name is kept as an alias
+// @Router /groups/{id}/merge [post]
+// @Param id path int true "Target group ID"
+// @Param request body MergeRequest true "Group to merge"
+// @Success 200 {object} storage.Group
+// @Failure 400
+// @Failure 404
+// @Failure 500
+func (h *GroupMergeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// songs of the source group are moved, cached ones still refer to it
+	serveMerge(w, r, h.GroupsTable, h.SongsCache)
+}
+
+// @Tags groups operations
+// @Summary Lists group aliases
+// @Router /groups/{id}/aliases [get]
+// @Param id path int true "Group ID"
+// @Success 200 {object} GroupAliasesResponse
+// @Failure 500
+func (h *GroupAliasesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	groupId, _ := strconv.Atoi(params["id"])
+
+	switch r.Method {
+
+	case http.MethodGet:
+		aliases, err := h.GroupsTable.Aliases(r.Context(), groupId)
+		if err != nil {
+			HandleDBSearchFail(w, err)
+			return
+		}
+		Render(w, r, &GroupAliasesResponse{ Aliases: aliases })
+
+	case http.MethodPost:
+		h.addAlias(w, r, groupId)
+
+	case http.MethodDelete:
+		h.deleteAlias(w, r, params, groupId)
+	}
+}
+
+// @Tags groups operations
+// @Summary Adds group alias
+// @Description Alias is an alternate spelling of the group name, it is used when adding and searching songs
+// @Router /groups/{id}/aliases [post]
+// @Param id path int true "Group ID"
+// @Param request body storage.GroupAlias true "Alias creation request"
+// @Success 201 {object} storage.GroupAlias
+// @Failure 400
+// @Failure 409
+// @Failure 500
+func (h *GroupAliasesHandler) addAlias(w http.ResponseWriter, r *http.Request, groupId int) {
+	var alias storage.GroupAlias
+	defer r.Body.Close()
+	if err := PasreJSON(r.Body, &alias); err != nil {
+		http.Error(w, "Can't parse request body", http.StatusBadRequest)
+		return
+	}
+
+	alias.GroupId = groupId
+	if strings.TrimSpace(alias.Alias) == "" {
+		http.Error(w, "alias is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.GroupsTable.AddAlias(r.Context(), &alias); err != nil {
+		pgErr, ok := err.(*pq.Error)
+		switch {
+		case ok && pgErr.Code == "23505":
+			http.Error(w, "Alias already exists", http.StatusConflict)
+		case ok && pgErr.Code == "23503":
+			http.Error(w, "Group not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Can't add alias into database", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// songs search by group matches aliases
+	h.SongsCache.Purge()
+	RenderStatus(w, r, http.StatusCreated, &alias)
+}
+
+// @Tags groups operations
+// @Summary Deletes group alias
+// @Router /groups/{id}/aliases/{aliasId} [delete]
+// @Param id path int true "Group ID"
+// @Param aliasId path int true "Alias ID"
+// @Success 204
+// @Failure 404
+// @Failure 500
+func (h *GroupAliasesHandler) deleteAlias(w http.ResponseWriter, r *http.Request, params map[string]string, groupId int) {
+	aliasId, _ := strconv.Atoi(params["aliasId"])
+	if err := h.GroupsTable.DeleteAlias(r.Context(), &storage.GroupAlias{ Id: aliasId, GroupId: groupId }); err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+	h.SongsCache.Purge()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveMerge merges the source record of the request into the target one,
+// cache is purged only after a successful merge and may be nil.
+func serveMerge[T any](w http.ResponseWriter, r *http.Request, table storage.Merger[T], cache storage.Purger) {
+	targetId, _ := strconv.Atoi(mux.Vars(r)["id"])
+
+	var request MergeRequest
+	defer r.Body.Close()
+	if err := PasreJSON(r.Body, &request); err != nil {
+		http.Error(w, "Can't parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if request.SourceId == 0 {
+		logger.Err.Println("no source id provided")
+		http.Error(w, "sourceId is required", http.StatusBadRequest)
+		return
+	}
+
+	merged, err := table.Merge(r.Context(), targetId, request.SourceId)
+	if err == storage.ErrSelfMerge {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	if cache != nil {
+		cache.Purge()
+	}
+	Render(w, r, merged)
+}
+
+func HandleDuplicateSong(w http.ResponseWriter, r *http.Request, e error) bool {
+	dupErr, ok := e.(*storage.DuplicateSongError)
+	if !ok {
+		return false
+	}
+	logger.Warn.Println("duplicated song - ", dupErr)
+	RenderStatus(w, r, http.StatusConflict, &SongConflictResponse{
+		Error: "Song already exists",
+		ExistingId: dupErr.ExistingId,
+	})
+	return true
+}
+
+// HandleInfoAPIFail answers with the status of the failure, upstream details are only logged.
+func HandleInfoAPIFail(w http.ResponseWriter, e error) {
+	logger.Err.Println("request to info api failed - ", e)
+	status := infoapi.HTTPStatus(e)
+	switch status {
+	case http.StatusNotFound:
+		http.Error(w, "Song not found by info API", status)
+	case http.StatusGatewayTimeout:
+		http.Error(w, "Info API request timed out", status)
+	default:
+		http.Error(w, "Info API is unavailable", status)
+	}
+}
+
 func HandleDBSearchFail(w http.ResponseWriter, e error) {
 	msg := fmt.Sprintf("Search failed Error: %v", e)
 	if e == sql.ErrNoRows {
@@ -353,6 +741,29 @@ func HandleDBSearchFail(w http.ResponseWriter, e error) {
 	http.Error(w, msg, http.StatusInternalServerError)
 }
 
+// Paginate returns items of the given page, zero page and limit mean 
+// the first page and all items respectively.
+func Paginate[T any](items []T, page, limit int) ([]T, int, int, bool) {
+	if page < 0 || limit < 0 {
+		return nil, page, limit, false
+	}
+
+	if page == 0 {
+		page = 1
+	}
+
+	if limit == 0 {
+		limit = len(items)
+	}
+
+	offset := limit * (page - 1)
+	end := min(offset + limit, len(items))
+	if offset < 0 || offset >= len(items) {
+		return nil, page, limit, false
+	}
+	return items[offset:end], page, limit, true
+}
+
 func ToInt(s string) (int, error) {
 	if s == "" {
 		return 0, nil
@@ -360,22 +771,51 @@ func ToInt(s string) (int, error) {
 	return strconv.Atoi(s)
 }
 
-func RenderJSON(w http.ResponseWriter, object interface{}) {
-	js, err := json.Marshal(object)
+// SetSongsLastModified sets Last-Modified to the latest update time of the songs.
+func SetSongsLastModified(w http.ResponseWriter, songs ...*storage.Song) {
+	var latest time.Time
+	for _, song := range songs {
+		if song.UpdatedAt != nil && song.UpdatedAt.After(latest) {
+			latest = *song.UpdatedAt
+		}
+	}
+	middleware.SetLastModified(w, latest)
+}
+
+// LastChange is Last-Modified of search responses: a delete or a group rename changes results
+// without touching updatedAt of found songs. It is read before the search, so results are never
+// older than it, and zero time means no Last-Modified.
+func LastChange(ctx context.Context, changes storage.ChangeMarker) time.Time {
+	changedAt, err := changes.LastChangeAt(ctx)
 	if err != nil {
-		http.Error(w, "Can't render JSON from object:", 500)
-		return
+		return time.Time{}
 	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(js)
+	return changedAt
 }
 
-func PasreJSON(r io.ReadCloser, object interface{}) {
-	body, _ := io.ReadAll(r)
-	if err := json.Unmarshal(body, object); err != nil {
-		fmt.Println("Can't unmarshal json:", err)
-		return
+// CacheControl reads Cache-Control policy of the route from the environment variable.
+func CacheControl(name, fallback string) string {
+	if policy, ok := os.LookupEnv(name); ok {
+		return policy
 	}
+	return fallback
+}
+
+// Render writes the object in the format chosen by Accept header: JSON, XML, CSV or MessagePack.
+func Render(w http.ResponseWriter, r *http.Request, object interface{}) {
+	render.Render(w, r, http.StatusOK, object)
+}
+
+func RenderStatus(w http.ResponseWriter, r *http.Request, status int, object interface{}) {
+	render.Render(w, r, status, object)
+}
+
+func PasreJSON(r io.ReadCloser, object interface{}) error {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, object)
 }
 
 func init() {
@@ -384,43 +824,220 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "mock-info" {
+		runMockInfo(os.Args[2:])
+		return
+	}
+
 	if err := godotenv.Load(); err != nil {
     	logger.Err.Fatalln("can't find .env file")
     }
 
+	// background workers and servers stop on SIGINT or SIGTERM, then traces left in the batch are flushed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	tracingConfig := tracing.ConfigFromEnv()
+	shutdownTracing, err := tracing.Setup(ctx, tracingConfig)
+	if err != nil {
+		logger.Err.Fatalln("can't configure tracing - ", err)
+	}
+
 	dbConn := storage.GetDBConnection()
 	defer dbConn.Close()
 
+	storage.MethodObserver = metrics.ObserveStorage
+	metrics.RegisterDB(dbConn, os.Getenv("DB_NAME"))
+
 	migrator, err := storage.CreateMigrator(dbConn)
 	if err != nil {
-		logger.Err.Printf("can't create migrator - %v\n", err) 
+		logger.Err.Fatalf("can't create migrator - %v\n", err) 
 	}
 
 	logger.Debug.Println("start migration process...")
-	migrator.MakeMigrations()
+	if err := migrator.MakeMigrations(); err != nil {
+		logger.Err.Fatalln("can't start with unapplied migrations - ", err)
+	}
+
+	webhooksTable := &storage.WebhooksTable{DB: dbConn}
+	dispatcher := webhooks.NewDispatcher(webhooksTable, webhooks.ConfigFromEnv())
+	dispatcher.Start(ctx)
+
+	changeLog := &storage.ChangeLogTable{DB: dbConn}
+	changeFeed := changefeed.NewFeed(changeLog, storage.GetDatabaseURL(true), changefeed.ConfigFromEnv())
+	if err := changeFeed.Start(ctx); err != nil {
+		logger.Err.Fatalln("can't follow change log - ", err)
+	}
 
-	songs := &storage.SongStorage{DB: dbConn}
+	outboxConfig := outbox.ConfigFromEnv()
+	sinks, err := outbox.SinksFromConfig(outboxConfig)
+	if err != nil {
+		logger.Err.Fatalln("can't configure outbox sinks - ", err)
+	}
+	relay := &outbox.Relay{ 
+		Outbox: &storage.OutboxTable{DB: dbConn}, Sinks: append([]outbox.Sink{ changeLog, dispatcher }, sinks...), Config: outboxConfig }
+	relay.Start(ctx)
+
+	songsTable := &storage.SongStorage{DB: dbConn}
+	if duplicates, err := songsTable.EnsureUniqueNames(ctx); err != nil {
+		logger.Err.Println("can't create unique song names index - ", err)
+	} else if duplicates > 0 {
+		logger.Warn.Printf("%d sets of songs have the same name in one group, duplicates are accepted until they are " +
+			"merged, see GET /api/v1/songs/duplicates and POST /api/v1/songs/{id}/merge\n", duplicates)
+	}
+	songs := storage.NewCachedStorage[storage.Song](songsTable, storage.CacheConfigFromEnv())
 	groups := &storage.GroupStorage{DB: dbConn}
+	metrics.RegisterCache("songs", songs.Stats)
 
 	query.SetQueryValidators()
 
 	router := mux.NewRouter()
+	router.Use(otelmux.Middleware(tracingConfig.ServiceName), metrics.HTTPMiddleware)
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
 
 	apiSongs := router.PathPrefix("/api/v1/songs").Subrouter()
-	apiSongs.Handle("", &SongSearchHandler{ SongsTable: songs }).Methods("GET")
+	searchCache := middleware.ConditionalGetMiddleware(CacheControl("CACHE_CONTROL_SEARCH", "public, max-age=30"))
+	songCache := middleware.ConditionalGetMiddleware(CacheControl("CACHE_CONTROL_SONG", "public, max-age=60"))
+	textCache := middleware.ConditionalGetMiddleware(CacheControl("CACHE_CONTROL_TEXT", "public, max-age=300"))
+
+	apiSongs.Handle("", searchCache(&SongSearchHandler{ SongsTable: songs, Changes: songsTable })).Methods("GET")
+	apiSongs.Handle("", &SongCreateHandler{ SongsTable: songs, GroupsTable: groups }).Methods("POST")
+	infoConfig := infoapi.ConfigFromEnv()
+	infoCache := &storage.InfoCacheTable{DB: dbConn}
+	infoProviders, err := infoapi.NewChain(infoConfig, metrics.InstrumentProvider(infoapi.NewClient(infoConfig)), infoCache)
+	if err != nil {
+		logger.Err.Fatalln("can't configure info providers - ", err)
+	}
+	logger.Debug.Printf("info providers: %s, merge policy: %s\n", infoProviders.Name(), infoProviders.Policy)
+
+	jobs := &storage.JobsTable{DB: dbConn}
+	enricher := enrichment.NewEnricher(songs, groups, jobs, infoProviders, enrichment.ConfigFromEnv())
+	enricher.SongsCache = songs
+	enricher.Start(ctx)
+
+	refresher := &enrichment.Refresher{ 
+		Metadata: songsTable, SongsCache: songs, InfoAPI: infoProviders, Config: enrichment.RefresherConfigFromEnv() }
+	refresher.Start(ctx)
+
 	apiSongs.Handle("/add", &SongAddHandler{ 
-		SongsTable: songs, GroupsTable: groups, DebugApiURL: os.Getenv("Debug_API_URL") }).Methods("POST")
+		SongsTable: songs, GroupsTable: groups, InfoAPI: infoProviders, Enricher: enricher }).Methods("POST")
+	apiSongs.Handle("/duplicates", &SongDuplicatesHandler{ SongsTable: songsTable }).Methods("GET")
 
 	apiSongOps := apiSongs.PathPrefix("/{id:[0-9]+}").Subrouter()
-	opsHandler := &SongOperationsHandler{ SongsTable: songs }
-	apiSongOps.Handle("", opsHandler).Methods("GET", "DELETE", "PUT")
-	apiSongOps.Handle("/text", opsHandler).Methods("GET")
+	translations := &storage.TranslationsTable{DB: dbConn}
+	opsHandler := &SongOperationsHandler{ SongsTable: songs, Translations: translations }
+	apiSongOps.Handle("", songCache(opsHandler)).Methods("GET", "DELETE", "PUT")
+	apiSongOps.Handle("/text", textCache(opsHandler)).Methods("GET")
+	apiSongOps.Handle("/text/search", &SongTextSearchHandler{ SongsTable: songs }).Methods("GET")
+	apiSongOps.Handle("/text/align", &TextAlignHandler{ SongsTable: songs, Translations: translations }).Methods("GET")
+
+	translationsHandler := &TranslationsHandler{ SongsTable: songs, Translations: translations, SongsCache: songs }
+	apiSongOps.Handle("/translations", translationsHandler).Methods("GET")
+	apiSongOps.Handle("/translations/{lang}", translationsHandler).Methods("GET", "PUT", "DELETE")
+	apiSongOps.Handle("/merge", &SongMergeHandler{ SongsTable: songs }).Methods("POST")
+
+	syncedHandler := &SyncedLyricsHandler{ SongsTable: songs, LyricsTable: &storage.SyncedLyricsTable{DB: dbConn} }
+	apiSongOps.Handle("/lyrics.lrc", syncedHandler).Methods("GET", "PUT", "DELETE")
+	apiSongOps.Handle("/lyrics", syncedHandler).Methods("GET")
+	apiSongOps.Handle("/lyrics/at", syncedHandler).Methods("GET")
+
+	router.Handle("/api/v1/jobs/{id:[0-9]+}", &JobHandler{ Jobs: jobs }).Methods("GET")
+	router.Handle("/api/v1/changes/stream", &ChangeStreamHandler{ 
+		Changes: changeLog, Feed: changeFeed, Heartbeat: changeFeed.Config.Heartbeat }).Methods("GET")
+
+	adminToken := os.Getenv("ADMIN_TOKEN")
+	if adminToken == "" {
+		logger.Warn.Println("ADMIN_TOKEN is not set, admin API is closed")
+	}
+	apiAdmin := router.PathPrefix("/api/v1/admin").Subrouter()
+	apiAdmin.Use(middleware.AdminAuthMiddleware(adminToken))
+	refresherHandler := &RefresherHandler{ Refresher: refresher, Metadata: songsTable }
+	apiAdmin.Handle("/refresher", refresherHandler).Methods("GET")
+	apiAdmin.Handle("/refresher/run", refresherHandler).Methods("POST")
+	apiAdmin.Handle("/refresher/changes", refresherHandler).Methods("GET")
+
+	infoCacheHandler := &InfoCacheHandler{ Cache: infoCache, TTL: infoConfig.CacheTTL }
+	apiAdmin.Handle("/info-cache", infoCacheHandler).Methods("GET", "DELETE")
+	apiAdmin.Handle("/info-cache/{id:[0-9]+}", infoCacheHandler).Methods("DELETE")
+	apiAdmin.Handle("/cache", &CacheStatsHandler{ Songs: songs }).Methods("GET", "DELETE")
+
+	webhooksHandler := &WebhooksHandler{ Hooks: webhooksTable, Dispatcher: dispatcher }
+	apiAdmin.Handle("/webhooks", webhooksHandler).Methods("GET", "POST")
+	apiAdmin.Handle("/webhooks/{id:[0-9]+}", webhooksHandler).Methods("GET", "PUT", "DELETE")
+	apiAdmin.Handle("/webhooks/{id:[0-9]+}/deliveries", webhooksHandler).Methods("GET")
+	apiAdmin.Handle("/webhooks/{id:[0-9]+}/deliveries/{deliveryId:[0-9]+}/redeliver", webhooksHandler).Methods("POST")
+
+	apiLyrics := router.PathPrefix("/api/v1/lyrics").Subrouter()
+	apiLyrics.Handle("/search", searchCache(&LyricsSearchHandler{ SongsTable: songs, Changes: songsTable })).Methods("GET")
+
+	apiGroups := router.PathPrefix("/api/v1/groups").Subrouter()
+	apiGroups.Handle("/{id:[0-9]+}/merge", &GroupMergeHandler{ GroupsTable: groups, SongsCache: songs }).Methods("POST")
+	aliasesHandler := &GroupAliasesHandler{ GroupsTable: groups, SongsCache: songs }
+	apiGroups.Handle("/{id:[0-9]+}/aliases", aliasesHandler).Methods("GET", "POST")
+	apiGroups.Handle("/{id:[0-9]+}/aliases/{aliasId:[0-9]+}", aliasesHandler).Methods("DELETE")
 	
+	graphqlHandler, err := gqlapi.NewHandler(&gqlapi.Resolver{ SongsTable: songs, GroupsTable: groups, AliasesTable: groups, GroupSongs: songsTable }, groups)
+	if err != nil {
+		logger.Err.Fatalln("can't parse graphql schema - ", err)
+	}
+	router.Handle("/graphql", graphqlHandler).Methods("POST")
+
+	grpcPort := os.Getenv("GRPC_PORT")
+	if grpcPort == "" {
+		grpcPort = "9090"
+	}
+	grpcListener, err := net.Listen("tcp", ":" + grpcPort)
+	if err != nil {
+		logger.Err.Fatalln("can't listen grpc port - ", err)
+	}
+	grpcServer := grpcapi.NewGRPCServer(&grpcapi.Server{ SongsTable: songs, GroupsTable: groups, InfoAPI: infoProviders })
+	go func() {
+		logger.Debug.Printf("start serving grpc on %s port...\n", grpcPort)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			logger.Err.Println("grpc server stopped - ", err)
+		}
+	}()
+
 	port := os.Getenv("SERV_PORT")
 	logger.Debug.Printf("start listening on %s port...\n", port)
 
 	router.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 
 	songApiRouter := middleware.AccessLogMiddleware(middleware.CORSMiddware(router))
-	http.ListenAndServe(":" + port, songApiRouter)
+	server := &http.Server{ Addr: ":" + port, Handler: songApiRouter }
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Err.Fatalln("http server stopped - ", err)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info.Println("shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout())
+	defer cancel()
+
+	// open change streams keep their connections until the timeout
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Warn.Println("http server didn't stop gracefully - ", err)
+	}
+
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+	select {
+	case <-grpcStopped:
+	case <-shutdownCtx.Done():
+		grpcServer.Stop()
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Warn.Println("can't flush traces - ", err)
+	}
+}
+
+// ShutdownTimeout reads SHUTDOWN_TIMEOUT, the time given to requests in flight on shutdown.
+func ShutdownTimeout() time.Duration {
+	return config.Duration("SHUTDOWN_TIMEOUT", 10 * time.Second)
 }
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"songsapi/changefeed"
+	"songsapi/infoapi"
+	"songsapi/logger"
+	"songsapi/storage"
+)
+
+func TestMain(m *testing.M) {
+	logger.DoConsoleLog()
+	os.Exit(m.Run())
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{ 1, 2, 3, 4, 5 }
+	tests := []struct {
+		name		string
+		page		int
+		limit		int
+		want		[]int
+		wantPage	int
+		wantLimit	int
+		wantOk		bool
+	}{
+		{ name: "defaults return everything", want: items, wantPage: 1, wantLimit: 5, wantOk: true },
+		{ name: "first page", page: 1, limit: 2, want: []int{ 1, 2 }, wantPage: 1, wantLimit: 2, wantOk: true },
+		{ name: "last page is short", page: 3, limit: 2, want: []int{ 5 }, wantPage: 3, wantLimit: 2, wantOk: true },
+		{ name: "limit without page", limit: 3, want: []int{ 1, 2, 3 }, wantPage: 1, wantLimit: 3, wantOk: true },
+		{ name: "page after the end", page: 4, limit: 2, wantPage: 4, wantLimit: 2 },
+		{ name: "negative limit", page: 1, limit: -1, wantPage: 1, wantLimit: -1 },
+		{ name: "negative limit on second page", page: 2, limit: -1, wantPage: 2, wantLimit: -1 },
+		{ name: "negative page", page: -1, limit: 2, wantPage: -1, wantLimit: 2 },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, page, limit, ok := Paginate(items, tt.page, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) || page != tt.wantPage || limit != tt.wantLimit || ok != tt.wantOk {
+				t.Errorf("Paginate(%d, %d) = %v, %d, %d, %v, want %v, %d, %d, %v", tt.page, tt.limit,
+					got, page, limit, ok, tt.want, tt.wantPage, tt.wantLimit, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestTextPaginationRejectsNegativeQuery(t *testing.T) {
+	for _, rawQuery := range []string{ "page=1&limit=-1", "page=2&limit=-1", "page=-1" } {
+		t.Run(rawQuery, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&TextPaginationHandler{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/songs/1/text?" + rawQuery, nil))
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestSongAddInfoAPIFailure(t *testing.T) {
+	tests := []struct {
+		name		string
+		upstream	http.HandlerFunc
+		closed		bool
+		wantStatus	int
+		wantBody	string
+	}{
+		{
+			name: "unknown song",
+			upstream: func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) },
+			wantStatus: http.StatusNotFound,
+			wantBody: "Song not found by info API",
+		},
+		{
+			name: "upstream error",
+			upstream: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "pq: password authentication failed for user admin", http.StatusInternalServerError)
+			},
+			wantStatus: http.StatusBadGateway,
+			wantBody: "Info API is unavailable",
+		},
+		{
+			name: "upstream timeout",
+			upstream: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusGatewayTimeout) },
+			wantStatus: http.StatusGatewayTimeout,
+			wantBody: "Info API request timed out",
+		},
+		{
+			name: "connection refused",
+			closed: true,
+			wantStatus: http.StatusBadGateway,
+			wantBody: "Info API is unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(tt.upstream)
+			if tt.closed {
+				upstream.Close()
+			} else {
+				defer upstream.Close()
+			}
+
+			handler := &SongAddHandler{ InfoAPI: infoapi.NewClient(infoapi.Config{
+				BaseURL: upstream.URL,
+				Timeout: time.Second,
+				BreakerThreshold: 100,
+				BreakerCooldown: time.Second,
+			}) }
+
+			w := httptest.NewRecorder()
+			body := strings.NewReader(`{"song": "Supermassive Black Hole", "group": "Muse"}`)
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/songs/add", body))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := strings.TrimSpace(w.Body.String()); got != tt.wantBody {
+				t.Errorf("body = %q, want %q", got, tt.wantBody)
+			}
+		})
+	}
+}
+
+// changeLog keeps changes with ids from first to last like the pruned change_log table.
+type changeLog struct {
+	first, last		int64
+}
+
+func (l *changeLog) ChangesSince(ctx context.Context, afterId int64, limit int) ([]*storage.Change, error) {
+	changes := make([]*storage.Change, 0)
+	for id := max(afterId + 1, l.first); id <= l.last && len(changes) < limit; id++ {
+		changes = append(changes, &storage.Change{ Id: id, Event: storage.EventSongUpdated, Data: json.RawMessage(`{}`) })
+	}
+	return changes, nil
+}
+
+func (l *changeLog) FirstChangeId(ctx context.Context) (int64, error) {
+	return l.first, nil
+}
+
+func (l *changeLog) LastChangeId(ctx context.Context) (int64, error) {
+	return l.last, nil
+}
+
+func (l *changeLog) PruneChanges(ctx context.Context, olderThan time.Duration) (int64, error) {
+	return 0, nil
+}
+
+func TestChangeStreamResume(t *testing.T) {
+	tests := []struct {
+		name			string
+		log				changeLog
+		lastEventId		string
+		want			string
+	}{
+		{ "without last event id", changeLog{ 5, 7 }, "", "" },
+		{ "replay", changeLog{ 5, 7 }, "5", "id: 6\nevent: song.updated\n|id: 7\nevent: song.updated\n" },
+		{ "replay from the oldest kept", changeLog{ 5, 7 }, "4", "id: 5\nevent: song.updated\n|id: 6|id: 7" },
+		{ "pruned changes", changeLog{ 5, 7 }, "3", "id: 7\nevent: reset\ndata: {\"oldestId\":5,\"lastId\":7}\n\n" },
+		{ "empty log", changeLog{ 0, 0 }, "3", "" },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &ChangeStreamHandler{
+				Changes: &tt.log,
+				Feed: changefeed.NewFeed(&tt.log, "", changefeed.Config{ Buffer: 1 }),
+				Heartbeat: time.Minute,
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50 * time.Millisecond)
+			defer cancel()
+			request := httptest.NewRequest(http.MethodGet, "/api/v1/changes/stream", nil).WithContext(ctx)
+			if tt.lastEventId != "" {
+				request.Header.Set("Last-Event-ID", tt.lastEventId)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, request)
+
+			body := strings.TrimPrefix(w.Body.String(), "retry: 3000\n\n")
+			if tt.want == "" && body != "" {
+				t.Errorf("body = %q, want nothing replayed", body)
+			}
+			for _, part := range strings.Split(tt.want, "|") {
+				if !strings.Contains(body, part) {
+					t.Errorf("body = %q, want it to contain %q", body, part)
+				}
+			}
+			if strings.Contains(tt.want, "reset") && strings.Contains(body, "song.updated") {
+				t.Errorf("body = %q, pruned log is replayed after reset", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "songs_http_requests_total",
+		Help: "HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "songs_http_request_duration_seconds",
+		Help: "HTTP request latency by route template and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	httpResponseSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "songs_http_response_size_bytes",
+		Help: "HTTP response body size by route template and method.",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+	}, []string{"route", "method"})
+)
+
+func init() {
+	Registry.MustRegister(httpRequests, httpDuration, httpResponseSize)
+}
+
+type responseRecorder struct {
+	http.ResponseWriter
+	status	int
+	size	int
+}
+
+func (rw *responseRecorder) WriteHeader(code int) {
+	rw.status = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseRecorder) Write(body []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(body)
+	rw.size += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach Flush of the original writer.
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// HTTPMiddleware is mux middleware, requests are labeled by the route template
+// like /api/v1/songs/{id}, so label values don't grow with ids.
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		start := time.Now()
+		recorder := &responseRecorder{ ResponseWriter: w, status: http.StatusOK }
+		next.ServeHTTP(recorder, r)
+
+		httpRequests.WithLabelValues(route, r.Method, strconv.Itoa(recorder.status)).Inc()
+		httpDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		httpResponseSize.WithLabelValues(route, r.Method).Observe(float64(recorder.size))
+	})
+}
@@ -0,0 +1,68 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"songsapi/infoapi"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	infoDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "songs_info_api_call_duration_seconds",
+		Help: "Duration of song info provider calls, including retries.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"provider"})
+
+	infoErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "songs_info_api_errors_total",
+		Help: "Failed song info provider calls by error kind.",
+	}, []string{"provider", "kind"})
+)
+
+func init() {
+	Registry.MustRegister(infoDuration, infoErrors)
+}
+
+type instrumentedProvider struct {
+	infoapi.SongInfoProvider
+}
+
+// InstrumentProvider measures calls of the provider and counts its errors.
+func InstrumentProvider(provider infoapi.SongInfoProvider) infoapi.SongInfoProvider {
+	return &instrumentedProvider{ SongInfoProvider: provider }
+}
+
+func (p *instrumentedProvider) SongInfo(ctx context.Context, group, song string) (*infoapi.SongInfo, error) {
+	start := time.Now()
+	info, err := p.SongInfoProvider.SongInfo(ctx, group, song)
+	infoDuration.WithLabelValues(p.Name()).Observe(time.Since(start).Seconds())
+	if err != nil {
+		infoErrors.WithLabelValues(p.Name(), errorKind(err)).Inc()
+	}
+	return info, err
+}
+
+func errorKind(err error) string {
+	var statusErr *infoapi.StatusError
+	var responseErr *infoapi.ResponseError
+	switch {
+	case errors.Is(err, infoapi.ErrSongNotFound):
+		return "not_found"
+	case errors.Is(err, infoapi.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, infoapi.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.As(err, &statusErr):
+		return "status"
+	case errors.As(err, &responseErr):
+		return "bad_response"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"songsapi/storage"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry holds every metric of the service, it is served by Handler.
+var Registry = prometheus.NewRegistry()
+
+var storageDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "songs_storage_method_duration_seconds",
+	Help: "Duration of storage method calls, including all their queries.",
+	Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"storage", "method"})
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		storageDuration,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{ Registry: Registry })
+}
+
+// RegisterDB exports database/sql connection pool stats of the database.
+func RegisterDB(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveStorage is storage.MethodObserver.
+func ObserveStorage(table, method string, took time.Duration) {
+	storageDuration.WithLabelValues(table, method).Observe(took.Seconds())
+}
+
+// RegisterCache exports counters of the storage cache and its hit ratios.
+func RegisterCache(name string, stats func() storage.CacheStats) {
+	labels := prometheus.Labels{ "cache": name }
+	Registry.MustRegister(&cacheCollector{
+		stats: stats,
+		hits: prometheus.NewDesc("songs_cache_hits_total", "Storage cache hits by operation.", []string{"op"}, labels),
+		misses: prometheus.NewDesc("songs_cache_misses_total", "Storage cache misses by operation.", []string{"op"}, labels),
+		ratio: prometheus.NewDesc("songs_cache_hit_ratio", "Storage cache hits to all lookups since the start.", []string{"op"}, labels),
+		evictions: prometheus.NewDesc("songs_cache_evictions_total", "Storage cache entries evicted by size or TTL.", nil, labels),
+		entries: prometheus.NewDesc("songs_cache_entries", "Storage cache entries.", nil, labels),
+	})
+}
+
+type cacheCollector struct {
+	stats		func() storage.CacheStats
+	hits		*prometheus.Desc
+	misses		*prometheus.Desc
+	ratio		*prometheus.Desc
+	evictions	*prometheus.Desc
+	entries		*prometheus.Desc
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+	lookups := []struct {
+		op				string
+		hits, misses	uint64
+	}{
+		{ "get", stats.GetHits, stats.GetMisses },
+		{ "find", stats.FindHits, stats.FindMisses },
+	}
+
+	for _, lookup := range lookups {
+		ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(lookup.hits), lookup.op)
+		ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(lookup.misses), lookup.op)
+		if total := lookup.hits + lookup.misses; total > 0 {
+			ch <- prometheus.MustNewConstMetric(c.ratio, prometheus.GaugeValue, float64(lookup.hits) / float64(total), lookup.op)
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries))
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConditionalGet(t *testing.T) {
+	modified := time.Date(2026, 10, 19, 12, 0, 0, 500 * int(time.Millisecond), time.UTC)
+	handler := ConditionalGetMiddleware("public, max-age=60")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		SetLastModified(w, modified)
+		w.Write([]byte(`{"id": 1}`))
+	}))
+
+	etag := func() string {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+		return recorder.Header().Get("ETag")
+	}()
+
+	tests := []struct {
+		name		string
+		header		map[string]string
+		wantStatus	int
+	}{
+		{ "no conditions", nil, http.StatusOK },
+		{ "same etag", map[string]string{ "If-None-Match": etag }, http.StatusNotModified },
+		{ "one of etags", map[string]string{ "If-None-Match": `"other", W/` + etag }, http.StatusNotModified },
+		{ "other etag", map[string]string{ "If-None-Match": `"other"` }, http.StatusOK },
+		{ "same second", map[string]string{ "If-Modified-Since": modified.Format(http.TimeFormat) }, http.StatusNotModified },
+		{ "later", map[string]string{ "If-Modified-Since": modified.Add(time.Hour).Format(http.TimeFormat) }, http.StatusNotModified },
+		{ "earlier", map[string]string{ "If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat) }, http.StatusOK },
+		{ "etag checked before date", map[string]string{
+			"If-None-Match": `"other"`, "If-Modified-Since": modified.Format(http.TimeFormat) }, http.StatusOK },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			for name, value := range tt.header {
+				request.Header.Set(name, value)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusNotModified && recorder.Body.Len() != 0 {
+				t.Errorf("304 has body %q", recorder.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS unique_song_name;
+DROP FUNCTION IF EXISTS title_similarity(TEXT, TEXT);
+DROP EXTENSION IF EXISTS fuzzystrmatch;
+DROP FUNCTION IF EXISTS normalize_title(TEXT);
@@ -0,0 +1,23 @@
+CREATE OR REPLACE FUNCTION normalize_title(title TEXT) RETURNS TEXT AS $$
+    SELECT lower(btrim(regexp_replace(title, '\s+', ' ', 'g')))
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE EXTENSION IF NOT EXISTS fuzzystrmatch;
+
+-- 1 - levenshtein(a, b) / max(length(a), length(b)), levenshtein doesn't take strings
+-- longer than 255 characters so such titles are only compared for equality
+CREATE OR REPLACE FUNCTION title_similarity(a TEXT, b TEXT) RETURNS FLOAT8 AS $$
+    SELECT CASE WHEN greatest(length(a), length(b)) > 255 THEN (a = b)::INT::FLOAT8
+        ELSE 1 - levenshtein(a, b)::FLOAT8 / greatest(length(a), length(b), 1) END
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- songs added before may already repeat each other, they are never deleted here: the index
+-- is left out and the server reports the duplicates on start until they are cleaned up
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM songs GROUP BY "groupId", normalize_title("name") HAVING count(*) > 1) THEN
+        RAISE WARNING 'songs repeat each other, unique_song_name index is not created';
+    ELSE
+        CREATE UNIQUE INDEX IF NOT EXISTS unique_song_name ON songs ("groupId", normalize_title("name"));
+    END IF;
+END $$;
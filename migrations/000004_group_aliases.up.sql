@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS group_aliases (
+    "id" SERIAL PRIMARY KEY,
+    "groupId" INTEGER NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    "alias" VARCHAR(255) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_group_alias ON group_aliases (normalize_title("alias"));
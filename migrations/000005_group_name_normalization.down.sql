@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS group_alias_normalized;
+DROP FUNCTION IF EXISTS normalize_group_name(TEXT);
@@ -0,0 +1,5 @@
+CREATE OR REPLACE FUNCTION normalize_group_name(name TEXT) RETURNS TEXT AS $$
+    SELECT regexp_replace(normalize_title(name), '^the ', '')
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE INDEX IF NOT EXISTS group_alias_normalized ON group_aliases (normalize_group_name("alias"));
@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS song_synced_lyrics (
+    "songId" INTEGER PRIMARY KEY REFERENCES songs(id) ON DELETE CASCADE,
+    "lrc" TEXT NOT NULL,
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT now()
+);
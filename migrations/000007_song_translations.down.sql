@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS song_translations;
+ALTER TABLE songs DROP COLUMN IF EXISTS "lang";
@@ -0,0 +1,10 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS "lang" VARCHAR(16) NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS song_translations (
+    "id" SERIAL PRIMARY KEY,
+    "songId" INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    "lang" VARCHAR(16) NOT NULL,
+    "text" TEXT NOT NULL,
+    "translator" VARCHAR(255) NOT NULL DEFAULT '',
+    CONSTRAINT unique_song_translation UNIQUE ("songId", "lang")
+);
@@ -0,0 +1,15 @@
+ALTER TABLE songs ALTER COLUMN "releaseDate" DROP NOT NULL;
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS "status" VARCHAR(32) NOT NULL DEFAULT 'enriched';
+
+CREATE TABLE IF NOT EXISTS enrichment_jobs (
+    "id" SERIAL PRIMARY KEY,
+    "songId" INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    "status" VARCHAR(32) NOT NULL DEFAULT 'queued',
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "lastError" TEXT NOT NULL DEFAULT '',
+    "runAt" TIMESTAMP NOT NULL DEFAULT now(),
+    "createdAt" TIMESTAMP NOT NULL DEFAULT now(),
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS enrichment_jobs_runnable ON enrichment_jobs ("runAt") WHERE "status" IN ('queued', 'retrying', 'running');
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS song_metadata_changes;
+ALTER TABLE songs DROP COLUMN IF EXISTS "enrichedAt";
@@ -0,0 +1,10 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS "enrichedAt" TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS song_metadata_changes (
+    "id" SERIAL PRIMARY KEY,
+    "songId" INTEGER NOT NULL REFERENCES songs(id) ON DELETE CASCADE,
+    "field" VARCHAR(32) NOT NULL,
+    "oldValue" TEXT NOT NULL,
+    "newValue" TEXT NOT NULL,
+    "changedAt" TIMESTAMP NOT NULL DEFAULT now()
+);
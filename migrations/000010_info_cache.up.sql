@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS song_info_cache (
+    "id" SERIAL PRIMARY KEY,
+    "group" VARCHAR(255) NOT NULL,
+    "song" VARCHAR(255) NOT NULL,
+    "releaseDate" VARCHAR(32) NOT NULL DEFAULT '',
+    "text" TEXT NOT NULL DEFAULT '',
+    "link" TEXT NOT NULL DEFAULT '',
+    "fetchedAt" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS song_info_cache_key ON song_info_cache (normalize_group_name("group"), normalize_title("song"));
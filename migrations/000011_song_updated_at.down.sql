@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS groups_touch_catalogue ON "groups";
+DROP TRIGGER IF EXISTS songs_update_touch_catalogue ON songs;
+DROP TRIGGER IF EXISTS songs_touch_catalogue ON songs;
+DROP FUNCTION IF EXISTS touch_catalogue();
+DROP TABLE IF EXISTS catalogue_changes;
+DROP TRIGGER IF EXISTS song_translations_touch_song ON song_translations;
+DROP FUNCTION IF EXISTS touch_song_of_translation();
+DROP TRIGGER IF EXISTS songs_updated_at ON songs;
+DROP FUNCTION IF EXISTS set_song_updated_at();
+ALTER TABLE songs DROP COLUMN IF EXISTS "updatedAt";
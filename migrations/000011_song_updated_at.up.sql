@@ -0,0 +1,51 @@
+ALTER TABLE songs ADD COLUMN IF NOT EXISTS "updatedAt" TIMESTAMP NOT NULL DEFAULT now();
+
+CREATE OR REPLACE FUNCTION set_song_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    -- a refresh without changes only moves "enrichedAt", the song stays the same for clients
+    IF NEW."updatedAt" = OLD."updatedAt" AND to_jsonb(NEW) - 'enrichedAt' = to_jsonb(OLD) - 'enrichedAt' THEN
+        RETURN NEW;
+    END IF;
+    NEW."updatedAt" = now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER songs_updated_at BEFORE UPDATE ON songs
+    FOR EACH ROW EXECUTE FUNCTION set_song_updated_at();
+
+-- translations are a part of the song text
+CREATE OR REPLACE FUNCTION touch_song_of_translation() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE songs SET "updatedAt" = now() WHERE "id" = COALESCE(NEW."songId", OLD."songId");
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER song_translations_touch_song AFTER INSERT OR UPDATE OR DELETE ON song_translations
+    FOR EACH ROW EXECUTE FUNCTION touch_song_of_translation();
+
+-- deletes and group renames change search results without touching "updatedAt" of found songs,
+-- so the time of the latest change of the whole catalogue is kept in one row
+CREATE TABLE IF NOT EXISTS catalogue_changes (
+    "id" BOOLEAN PRIMARY KEY DEFAULT true CHECK ("id"),
+    "changedAt" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+INSERT INTO catalogue_changes DEFAULT VALUES ON CONFLICT DO NOTHING;
+
+CREATE OR REPLACE FUNCTION touch_catalogue() RETURNS TRIGGER AS $$
+BEGIN
+    UPDATE catalogue_changes SET "changedAt" = now();
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER songs_touch_catalogue AFTER INSERT OR DELETE OR TRUNCATE ON songs
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalogue();
+
+CREATE OR REPLACE TRIGGER songs_update_touch_catalogue AFTER UPDATE ON songs
+    FOR EACH ROW WHEN (OLD."updatedAt" IS DISTINCT FROM NEW."updatedAt") EXECUTE FUNCTION touch_catalogue();
+
+CREATE OR REPLACE TRIGGER groups_touch_catalogue AFTER INSERT OR UPDATE OR DELETE OR TRUNCATE ON "groups"
+    FOR EACH STATEMENT EXECUTE FUNCTION touch_catalogue();
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    "id" SERIAL PRIMARY KEY,
+    "url" TEXT NOT NULL,
+    "events" TEXT[] NOT NULL,
+    "secret" TEXT NOT NULL,
+    "active" BOOLEAN NOT NULL DEFAULT true,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    "id" SERIAL PRIMARY KEY,
+    "webhookId" INTEGER NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    "event" VARCHAR(32) NOT NULL,
+    "payload" TEXT NOT NULL,
+    "status" VARCHAR(16) NOT NULL DEFAULT 'pending',
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "responseStatus" INTEGER NOT NULL DEFAULT 0,
+    "lastError" TEXT NOT NULL DEFAULT '',
+    "runAt" TIMESTAMP NOT NULL DEFAULT now(),
+    "createdAt" TIMESTAMP NOT NULL DEFAULT now(),
+    "deliveredAt" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_runnable ON webhook_deliveries ("runAt") WHERE "status" IN ('pending', 'retrying', 'sending');
+CREATE INDEX IF NOT EXISTS webhook_deliveries_webhook ON webhook_deliveries ("webhookId", "id");
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS change_log_notify ON change_log;
+DROP FUNCTION IF EXISTS notify_change_log();
+DROP TABLE IF EXISTS change_log;
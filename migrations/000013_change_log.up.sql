@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS change_log (
+    "id" BIGSERIAL PRIMARY KEY,
+    "event" VARCHAR(32) NOT NULL,
+    "data" TEXT NOT NULL,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT now()
+);
+
+-- every replica listens to the channel and reads new rows from the table,
+-- so all of them stream the same changes in the same order
+CREATE OR REPLACE FUNCTION notify_change_log() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM pg_notify('change_log', NEW."id"::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER change_log_notify AFTER INSERT ON change_log
+    FOR EACH ROW EXECUTE FUNCTION notify_change_log();
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS webhook_deliveries_outbox;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS "outboxId";
+ALTER TABLE change_log DROP COLUMN IF EXISTS "outboxId";
+DROP TABLE IF EXISTS outbox_deliveries;
+DROP TABLE IF EXISTS outbox;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    "id" BIGSERIAL PRIMARY KEY,
+    "aggregateType" VARCHAR(16) NOT NULL,
+    "aggregateId" INTEGER NOT NULL,
+    "event" VARCHAR(32) NOT NULL,
+    "data" TEXT NOT NULL,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT now(),
+    -- set once the event is published to every sink
+    "publishedAt" TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS outbox_unpublished ON outbox ("id") WHERE "publishedAt" IS NULL;
+CREATE INDEX IF NOT EXISTS outbox_unpublished_aggregate ON outbox ("aggregateType", "aggregateId", "id") WHERE "publishedAt" IS NULL;
+
+-- every sink keeps its own delivery state, so a failing sink doesn't hold back the others
+CREATE TABLE IF NOT EXISTS outbox_deliveries (
+    "outboxId" BIGINT NOT NULL REFERENCES outbox("id") ON DELETE CASCADE,
+    "sink" VARCHAR(32) NOT NULL,
+    "status" VARCHAR(16) NOT NULL,
+    "attempts" INTEGER NOT NULL DEFAULT 0,
+    "nextAttemptAt" TIMESTAMP NOT NULL DEFAULT now(),
+    "lastError" TEXT NOT NULL DEFAULT '',
+    "publishedAt" TIMESTAMP,
+    PRIMARY KEY ("outboxId", "sink")
+);
+
+-- the relay delivers events at least once, these keep the internal sinks idempotent
+ALTER TABLE change_log ADD COLUMN IF NOT EXISTS "outboxId" BIGINT UNIQUE;
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS "outboxId" BIGINT;
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_outbox ON webhook_deliveries ("webhookId", "outboxId");
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"net/http"
+	"os"
+
+	"songsapi/infoapi"
+	"songsapi/logger"
+)
+
+// runMockInfo starts the mock info API server, it is used as `songsapi mock-info [flags]`.
+func runMockInfo(args []string) {
+	flags := flag.NewFlagSet("mock-info", flag.ExitOnError)
+	addr := flags.String("addr", ":8082", "address to listen on")
+	fixtures := flags.String("fixtures", "fixtures", "directory with <group>/<song>.json|yaml files")
+	latency := flags.Int("latency", 0, "delay of every response in milliseconds")
+	jitter := flags.Int("jitter", 0, "random extra delay up to this number of milliseconds")
+	errorRate := flags.Float64("error-rate", 0, "share of requests answered with error, from 0 to 1")
+	errorStatus := flags.Int("error-status", http.StatusInternalServerError, "status code of injected errors")
+	flags.Parse(args)
+
+	if _, err := os.Stat(*fixtures); err != nil {
+		logger.Err.Fatalln("can't open fixtures directory - ", err)
+	}
+
+	cfg := infoapi.MockConfig{ LatencyMs: *latency, JitterMs: *jitter, ErrorRate: *errorRate, ErrorStatus: *errorStatus }
+	if err := cfg.Validate(); err != nil {
+		logger.Err.Fatalln("invalid mock config - ", err)
+	}
+
+	server := infoapi.NewMockServer(&infoapi.FixtureProvider{ Dir: *fixtures }, cfg)
+
+	logger.Info.Printf("mock info API is listening on %s, fixtures from %s\n", *addr, *fixtures)
+	if err := http.ListenAndServe(*addr, server.Handler()); err != nil {
+		logger.Err.Fatalln(err)
+	}
+}
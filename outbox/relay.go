@@ -0,0 +1,204 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"songsapi/config"
+	"songsapi/logger"
+	"songsapi/storage"
+)
+
+// Sink receives outbox events. Events are delivered at least once, so sinks
+// should tolerate the same event id more than once.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, event *storage.OutboxEvent) error
+}
+
+type Config struct {
+	Sinks			[]string
+	File			string
+	HTTPURL			string
+	HTTPTimeout		time.Duration
+	PollInterval	time.Duration
+	BatchSize		int
+	Lease			time.Duration
+	MaxAttempts		int
+	RetryBackoff	time.Duration
+	MaxBackoff		time.Duration
+	Retention		time.Duration
+}
+
+// Relay publishes events written into the outbox by storage to every sink. Each sink gets
+// events of an aggregate in the id order, an event failed in the sink holds back later events
+// of its aggregate in that sink only, until it is published or dead.
+type Relay struct {
+	Outbox		storage.OutboxStorage
+	Sinks		[]Sink
+	Config		Config
+}
+
+func ConfigFromEnv() Config {
+	return Config{
+		Sinks: config.List("OUTBOX_SINKS", []string{}),
+		File: config.String("OUTBOX_FILE", "outbox.jsonl"),
+		HTTPURL: os.Getenv("OUTBOX_HTTP_URL"),
+		HTTPTimeout: config.Duration("OUTBOX_HTTP_TIMEOUT", 10 * time.Second),
+		PollInterval: config.Duration("OUTBOX_POLL_INTERVAL", 500 * time.Millisecond),
+		BatchSize: config.Int("OUTBOX_BATCH_SIZE", 100),
+		Lease: config.Duration("OUTBOX_LEASE", time.Minute),
+		MaxAttempts: config.Int("OUTBOX_MAX_ATTEMPTS", 15),
+		RetryBackoff: config.Duration("OUTBOX_RETRY_BACKOFF", time.Second),
+		MaxBackoff: config.Duration("OUTBOX_MAX_BACKOFF", 5 * time.Minute),
+		Retention: config.Duration("OUTBOX_RETENTION", 24 * time.Hour),
+	}
+}
+
+// SinksFromConfig builds external sinks listed in OUTBOX_SINKS: stdout, file and http.
+func SinksFromConfig(cfg Config) ([]Sink, error) {
+	sinks := make([]Sink, 0, len(cfg.Sinks))
+	for _, name := range cfg.Sinks {
+		switch name {
+		case "stdout":
+			sinks = append(sinks, NewWriterSink("stdout", os.Stdout))
+		case "file":
+			sink, err := NewFileSink(cfg.File)
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case "http":
+			if cfg.HTTPURL == "" {
+				return nil, fmt.Errorf("OUTBOX_HTTP_URL is required by http sink")
+			}
+			sinks = append(sinks, NewHTTPSink(cfg.HTTPURL, cfg.HTTPTimeout))
+		default:
+			return nil, fmt.Errorf("unknown outbox sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+func (r *Relay) Start(ctx context.Context) {
+	names := r.sinkNames()
+	logger.Debug.Printf("starting outbox relay to %s...\n", strings.Join(names, ", "))
+	for _, sink := range r.Sinks {
+		go r.run(ctx, sink)
+	}
+	go r.maintain(ctx, names)
+}
+
+func (r *Relay) sinkNames() []string {
+	names := make([]string, len(r.Sinks))
+	for i, sink := range r.Sinks {
+		names[i] = sink.Name()
+	}
+	return names
+}
+
+// run relays events to the sink, every sink has its own worker and delivery state,
+// so a slow or failing sink doesn't hold back the others.
+func (r *Relay) run(ctx context.Context, sink Sink) {
+	ticker := time.NewTicker(r.Config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for r.runOnce(ctx, sink) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce claims due events of the sink and publishes them, no lock is held while the sink is called.
+// It reports whether anything was published, so the next events are claimed right away.
+func (r *Relay) runOnce(ctx context.Context, sink Sink) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	events, err := r.Outbox.ClaimOutbox(ctx, sink.Name(), r.Config.BatchSize, r.Config.Lease)
+	if err != nil {
+		return false
+	}
+
+	published := false
+	for _, event := range events {
+		retryAfter := r.publish(ctx, sink, event)
+		if err := r.Outbox.FinishOutbox(ctx, sink.Name(), event, retryAfter); err != nil {
+			logger.Err.Printf("can't save result of outbox event %d for %s sink - %v\n", event.Id, sink.Name(), err)
+			continue
+		}
+		published = published || event.Status == storage.OutboxPublished
+	}
+
+	return published
+}
+
+// publish sends the event to the sink and sets its status, it returns the delay before the next attempt.
+// Events failed MaxAttempts times are dead, they no longer hold back later events of their aggregate.
+func (r *Relay) publish(ctx context.Context, sink Sink, event *storage.OutboxEvent) time.Duration {
+	err := sink.Publish(ctx, event)
+	if err == nil {
+		event.Status, event.LastError = storage.OutboxPublished, ""
+		return 0
+	}
+
+	event.LastError = err.Error()
+	if r.Config.MaxAttempts > 0 && event.Attempts >= r.Config.MaxAttempts {
+		logger.Err.Printf("outbox event %d (%s) is dead in %s sink after %d attempts - %v\n",
+			event.Id, event.Event, sink.Name(), event.Attempts, err)
+		event.Status = storage.OutboxDead
+		return 0
+	}
+
+	backoff := r.backoff(event.Attempts)
+	logger.Warn.Printf("outbox event %d (%s) failed in %s sink, retry in %s - %v\n", event.Id, event.Event, sink.Name(), backoff, err)
+	event.Status = storage.OutboxRetrying
+	return backoff
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	backoff := r.Config.RetryBackoff << min(attempts - 1, 16)
+	if backoff > r.Config.MaxBackoff || backoff <= 0 {
+		backoff = r.Config.MaxBackoff
+	}
+	return backoff
+}
+
+// maintain marks events done in every sink as published and prunes old ones.
+func (r *Relay) maintain(ctx context.Context, sinks []string) {
+	ticker := time.NewTicker(r.Config.PollInterval)
+	defer ticker.Stop()
+	prune := time.NewTicker(time.Hour)
+	defer prune.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-prune.C:
+			r.prune(ctx)
+		case <-ticker.C:
+			r.Outbox.CompleteOutbox(ctx, sinks)
+		}
+	}
+}
+
+func (r *Relay) prune(ctx context.Context) {
+	if r.Config.Retention <= 0 {
+		return
+	}
+
+	if deleted, err := r.Outbox.PruneOutbox(ctx, r.Config.Retention); err == nil && deleted > 0 {
+		logger.Info.Printf("%d published events deleted from outbox\n", deleted)
+	}
+}
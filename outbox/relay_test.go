@@ -0,0 +1,136 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"songsapi/logger"
+	"songsapi/storage"
+)
+
+func TestMain(m *testing.M) {
+	logger.DoConsoleLog()
+	os.Exit(m.Run())
+}
+
+type finished struct {
+	status		string
+	retryAfter	time.Duration
+}
+
+// fakeOutbox returns the events given for every sink and records results of the attempts.
+type fakeOutbox struct {
+	storage.OutboxStorage
+
+	claims		map[string][]*storage.OutboxEvent
+	finished	map[string]map[int64]finished
+}
+
+func (o *fakeOutbox) ClaimOutbox(ctx context.Context, sink string, limit int, lease time.Duration) ([]*storage.OutboxEvent, error) {
+	events := o.claims[sink]
+	if len(events) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	claimed := make([]*storage.OutboxEvent, len(events))
+	for i, event := range events {
+		copied := *event
+		claimed[i] = &copied
+	}
+	return claimed, nil
+}
+
+func (o *fakeOutbox) FinishOutbox(ctx context.Context, sink string, event *storage.OutboxEvent, retryAfter time.Duration) error {
+	if o.finished[sink] == nil {
+		o.finished[sink] = make(map[int64]finished)
+	}
+	o.finished[sink][event.Id] = finished{ status: event.Status, retryAfter: retryAfter }
+	return nil
+}
+
+type fakeSink struct {
+	name		string
+	err			error
+	published	[]int64
+}
+
+func (s *fakeSink) Name() string {
+	return s.name
+}
+
+func (s *fakeSink) Publish(ctx context.Context, event *storage.OutboxEvent) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.published = append(s.published, event.Id)
+	return nil
+}
+
+func TestRunOnce(t *testing.T) {
+	cfg := Config{ MaxAttempts: 3, RetryBackoff: time.Second, MaxBackoff: time.Minute }
+	tests := []struct {
+		name			string
+		attempts		int
+		sinkErr			error
+		wantStatus		string
+		wantRetry		time.Duration
+		wantPublished	bool
+	}{
+		{ name: "published", attempts: 1, wantStatus: storage.OutboxPublished, wantPublished: true },
+		{ name: "first failure backs off", attempts: 1, sinkErr: errors.New("down"), wantStatus: storage.OutboxRetrying, wantRetry: time.Second },
+		{ name: "backoff grows", attempts: 2, sinkErr: errors.New("down"), wantStatus: storage.OutboxRetrying, wantRetry: 2 * time.Second },
+		{ name: "dead after max attempts", attempts: 3, sinkErr: errors.New("down"), wantStatus: storage.OutboxDead },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &fakeSink{ name: "test", err: tt.sinkErr }
+			outbox := &fakeOutbox{
+				claims: map[string][]*storage.OutboxEvent{ "test": {{ Id: 7, Event: storage.EventSongUpdated, Attempts: tt.attempts }} },
+				finished: make(map[string]map[int64]finished),
+			}
+			relay := &Relay{ Outbox: outbox, Sinks: []Sink{ sink }, Config: cfg }
+
+			if published := relay.runOnce(context.Background(), sink); published != tt.wantPublished {
+				t.Errorf("runOnce() = %v, want %v", published, tt.wantPublished)
+			}
+
+			got := outbox.finished["test"][7]
+			if got.status != tt.wantStatus || got.retryAfter != tt.wantRetry {
+				t.Errorf("finished with %s, retry after %s, want %s, retry after %s", got.status, got.retryAfter, tt.wantStatus, tt.wantRetry)
+			}
+		})
+	}
+}
+
+func TestRunOnceKeepsSinksApart(t *testing.T) {
+	events := []*storage.OutboxEvent{{ Id: 1, Attempts: 1 }, { Id: 2, Attempts: 1 }}
+	outbox := &fakeOutbox{
+		claims: map[string][]*storage.OutboxEvent{ "down": events, "up": events },
+		finished: make(map[string]map[int64]finished),
+	}
+	down := &fakeSink{ name: "down", err: errors.New("connection refused") }
+	up := &fakeSink{ name: "up" }
+	relay := &Relay{ Outbox: outbox, Sinks: []Sink{ down, up }, Config: Config{ MaxAttempts: 5, RetryBackoff: time.Second, MaxBackoff: time.Minute } }
+
+	relay.runOnce(context.Background(), down)
+	if !relay.runOnce(context.Background(), up) {
+		t.Fatal("events weren't published to the working sink")
+	}
+
+	for _, id := range []int64{ 1, 2 } {
+		if status := outbox.finished["up"][id].status; status != storage.OutboxPublished {
+			t.Errorf("event %d is %s in the working sink", id, status)
+		}
+		if status := outbox.finished["down"][id].status; status != storage.OutboxRetrying {
+			t.Errorf("event %d is %s in the failing sink", id, status)
+		}
+	}
+	if len(up.published) != 2 {
+		t.Errorf("working sink got %d events, want 2", len(up.published))
+	}
+}
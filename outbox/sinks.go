@@ -0,0 +1,106 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"songsapi/storage"
+)
+
+// WriterSink writes every event as a JSON line.
+type WriterSink struct {
+	name	string
+	mu		sync.Mutex
+	out		io.Writer
+}
+
+func NewWriterSink(name string, out io.Writer) *WriterSink {
+	return &WriterSink{ name: name, out: out }
+}
+
+func (s *WriterSink) Name() string {
+	return s.name
+}
+
+func (s *WriterSink) Publish(ctx context.Context, event *storage.OutboxEvent) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.out.Write(append(line, '\n'))
+	return err
+}
+
+// FileSink appends events to the JSON lines file, the file is synced after every event.
+type FileSink struct {
+	*WriterSink
+	file	*os.File
+}
+
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("can't open outbox file - %w", err)
+	}
+	return &FileSink{ WriterSink: NewWriterSink("file", file), file: file }, nil
+}
+
+func (s *FileSink) Publish(ctx context.Context, event *storage.OutboxEvent) error {
+	if err := s.WriterSink.Publish(ctx, event); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// HTTPSink posts every event as JSON, any response but 2xx is a failure.
+// Header X-Event-Id carries the event id, so the receiver can drop duplicates.
+type HTTPSink struct {
+	URL		string
+	Client	*http.Client
+}
+
+func NewHTTPSink(url string, timeout time.Duration) *HTTPSink {
+	return &HTTPSink{ URL: url, Client: &http.Client{ Timeout: timeout } }
+}
+
+func (s *HTTPSink) Name() string {
+	return "http"
+}
+
+func (s *HTTPSink) Publish(ctx context.Context, event *storage.OutboxEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Id", strconv.FormatInt(event.Id, 10))
+	req.Header.Set("X-Event-Type", event.Event)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("responded with %d", resp.StatusCode)
+	}
+	return nil
+}
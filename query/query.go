@@ -2,6 +2,7 @@ package query
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -15,9 +16,11 @@ type Query interface {
 
 type SongQuery struct {
 	Name        string
-	Group       string	`sql_related:"name"`
+	Group       string	`sql:"group"`
 	ReleaseDate string 	`valid:"date"`
 	Text        string	`sql:"substring"`
+	// TextLine matches the substring within one line of the text, like lyrics search highlights it
+	TextLine	string	`sql:"line" schema:"-"`
 	Link        string 	`valid:"link"`
 	Page        int		`sql:"-"`
 	Limit		int		`sql:"-"`
@@ -25,16 +28,22 @@ type SongQuery struct {
 
 type GroupQuery struct {
 	Name string
+	// Exact matches normalized name or one of the group aliases instead of a substring
+	Exact bool
 }
 
 func (q *SongQuery) Validate() error {
+	if q.Page < 0 || q.Limit < 0 {
+		return errors.New("page and limit must be positive numbers")
+	}
 	_, err := govalidator.ValidateStruct(*q)
 	return err
 }
 
 func (q *SongQuery) GenerateSQL() string {
 	buf := new(bytes.Buffer)
-	buf.WriteString(`SELECT s."id", s."name", s."releaseDate", s."text", s."link", g."name" from songs s 
+	buf.WriteString(`SELECT s."id", s."name", s."releaseDate", s."text", s."link", g."name", s."lang", s."status", s."enrichedAt", 
+		s."updatedAt", s."groupId" from songs s 
 		JOIN "groups" g ON s."groupId" = g."id"`)
 	v := reflect.ValueOf(*q)
 
@@ -44,6 +53,7 @@ func (q *SongQuery) GenerateSQL() string {
 	} 
 		
 	offset := limit * (q.Page - 1)
+	conditions := 0
 	
 	for i := 0; i < v.NumField(); i++ {
 		field := v.Field(i)
@@ -55,18 +65,32 @@ func (q *SongQuery) GenerateSQL() string {
 
 		var (
 			tableShort rune = 's'
-			searchPattern string = fmt.Sprintf(`= '%v'`, field.Interface())
+			value string = quoteValue(field.Interface())
+			searchPattern string = fmt.Sprintf(`= '%s'`, value)
 			fieldName string = fieldType.Name
 		)
 
-		if i == 0 {
+		if conditions == 0 {
 			buf.WriteString(` WHERE `)
 		} else {
 			buf.WriteString(` AND `)
 		}
+		conditions++
+
+		if fieldType.Tag.Get("sql") == "group" {
+			fmt.Fprintf(buf, `(normalize_group_name(g."name") = normalize_group_name('%[1]s') OR g."id" IN 
+				(SELECT "groupId" FROM group_aliases WHERE normalize_group_name("alias") = normalize_group_name('%[1]s')))`, value)
+			continue
+		}
+
+		if fieldType.Tag.Get("sql") == "line" {
+			fmt.Fprintf(buf, `EXISTS (SELECT 1 FROM regexp_split_to_table(s."text", '\r?\n|\r') AS line WHERE line ILIKE '%%%s%%' ESCAPE '\')`,
+				likeValue(field.Interface()))
+			continue
+		}
 
 		if fieldType.Tag.Get("sql") == "substring" {
-			searchPattern = fmt.Sprintf(`LIKE '%%%v%%'`, field.Interface())
+			searchPattern = fmt.Sprintf(`ILIKE '%%%s%%' ESCAPE '\'`, likeValue(field.Interface()))
 		}
 
 		if related := fieldType.Tag.Get("sql_related"); related != "" {
@@ -84,6 +108,17 @@ func (q *SongQuery) GenerateSQL() string {
 	return buf.String()
 }
 
+func quoteValue(value interface{}) string {
+	return strings.ReplaceAll(fmt.Sprint(value), "'", "''")
+}
+
+// likeEscaper makes LIKE wildcards of user input literal, the pattern is used with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func likeValue(value interface{}) string {
+	return quoteValue(likeEscaper.Replace(fmt.Sprint(value)))
+}
+
 func (q *GroupQuery) Validate() error {
 	return nil
 }
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"songsapi/enrichment"
+	"songsapi/logger"
+	"songsapi/storage"
+)
+
+type RefresherHandler struct {
+	Refresher	*enrichment.Refresher
+	Metadata	storage.MetadataRefreshStorage
+}
+
+type RefresherStatsResponse struct {
+	Running		bool
+	LastRun		*enrichment.RunStats
+}
+
+type MetadataChangesResponse struct {
+	Changes		[]*storage.MetadataChange
+}
+
+func (h *RefresherHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+
+	case r.Method == http.MethodPost:
+		h.run(w)
+
+	case strings.HasSuffix(r.URL.Path, "/changes"):
+		h.changes(w, r)
+
+	default:
+		h.stats(w, r)
+	}
+}
+
+// @Tags admin
+// @Summary Returns metadata refresher stats
+// @Router /admin/refresher [get]
+// @Security AdminToken
+// @Failure 401
+// @Produce json
+// @Success 200 {object} RefresherStatsResponse
+func (h *RefresherHandler) stats(w http.ResponseWriter, r *http.Request) {
+	Render(w, r, &RefresherStatsResponse{ Running: h.Refresher.Running(), LastRun: h.Refresher.LastRun() })
+}
+
+// @Tags admin
+// @Summary Starts metadata refresh right away
+// @Router /admin/refresher/run [post]
+// @Security AdminToken
+// @Failure 401
+// @Success 202
+// @Failure 409
+func (h *RefresherHandler) run(w http.ResponseWriter) {
+	if !h.Refresher.RunAsync() {
+		http.Error(w, "Refresh is already running", http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// @Tags admin
+// @Summary Returns fields changed by metadata refresh
+// @Router /admin/refresher/changes [get]
+// @Security AdminToken
+// @Failure 401
+// @Produce json
+// @Param songId query int false "Song ID"
+// @Param limit query int false "Maximum number of changes to return, default 100"
+// @Success 200 {object} MetadataChangesResponse
+// @Failure 400
+// @Failure 500
+func (h *RefresherHandler) changes(w http.ResponseWriter, r *http.Request) {
+	songId, songErr := ToInt(r.URL.Query().Get("songId"))
+	limit, limitErr := ToInt(r.URL.Query().Get("limit"))
+	if songErr != nil || limitErr != nil {
+		logger.Err.Println("bad request query")
+		http.Error(w, "songId and limit must be numbers", http.StatusBadRequest)
+		return
+	}
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	changes, err := h.Metadata.MetadataChanges(r.Context(), songId, limit)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	Render(w, r, &MetadataChangesResponse{ Changes: changes })
+}
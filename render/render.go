@@ -0,0 +1,195 @@
+package render
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"songsapi/logger"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Table is implemented by list responses which can be rendered as CSV.
+type Table interface {
+	CSVHeader() []string
+	CSVRows() [][]string
+}
+
+var ErrNotAcceptable = errors.New("response can't be rendered in any of accepted formats")
+
+type format struct {
+	contentType	string
+	mediaTypes	[]string
+	encode		func(buf *bytes.Buffer, object any) error
+}
+
+// formats in order of preference, the first one is used for */* and missing Accept header
+var formats = []format{
+	{ "application/json", []string{"application/json"}, encodeJSON },
+	{ "application/xml; charset=utf-8", []string{"application/xml", "text/xml"}, encodeXML },
+	{ "text/csv; charset=utf-8", []string{"text/csv"}, encodeCSV },
+	{ "application/msgpack", []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}, encodeMsgPack },
+}
+
+// Render writes the object in the format preferred by Accept header of the request.
+// Formats which can't represent the object are skipped, 406 is answered if nothing is left.
+func Render(w http.ResponseWriter, r *http.Request, status int, object any) {
+	w.Header().Add("Vary", "Accept")
+
+	body, contentType, err := Encode(r.Header.Get("Accept"), object)
+	if err == ErrNotAcceptable {
+		http.Error(w, "Supported formats: application/json, application/xml, text/csv (lists only), application/msgpack", 
+			http.StatusNotAcceptable)
+		return
+	}
+	if err != nil {
+		logger.Err.Println("can't render response - ", err)
+		http.Error(w, "Can't render response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// Encode returns the object in the best format for accept and its content type.
+func Encode(accept string, object any) ([]byte, string, error) {
+	var lastErr error = ErrNotAcceptable
+	for _, f := range acceptedFormats(accept) {
+		buf := new(bytes.Buffer)
+		err := f.encode(buf, object)
+		if err == nil {
+			return buf.Bytes(), f.contentType, nil
+		}
+		if !isUnsupported(err) {
+			lastErr = err
+		}
+	}
+	return nil, "", lastErr
+}
+
+type acceptRange struct {
+	mediaType	string
+	quality		float64
+}
+
+// acceptedFormats lists formats matching accept in the client preference order.
+func acceptedFormats(accept string) []format {
+	if strings.TrimSpace(accept) == "" {
+		return formats
+	}
+
+	ranges := make([]acceptRange, 0)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		accepted := acceptRange{ mediaType: strings.ToLower(strings.TrimSpace(params[0])), quality: 1 }
+		for _, param := range params[1:] {
+			if name, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && name == "q" {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					accepted.quality = q
+				}
+			}
+		}
+		if accepted.quality > 0 && accepted.mediaType != "" {
+			ranges = append(ranges, accepted)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].quality > ranges[j].quality })
+
+	matched := make([]format, 0, len(formats))
+	seen := make(map[string]bool)
+	for _, accepted := range ranges {
+		for _, f := range formats {
+			if !seen[f.contentType] && f.matches(accepted.mediaType) {
+				seen[f.contentType] = true
+				matched = append(matched, f)
+			}
+		}
+	}
+	return matched
+}
+
+func (f format) matches(mediaRange string) bool {
+	if mediaRange == "*/*" {
+		return true
+	}
+	for _, mediaType := range f.mediaTypes {
+		if mediaType == mediaRange || strings.HasSuffix(mediaRange, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(mediaRange, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// unsupportedError means the format can't represent the object, so another one is tried.
+type unsupportedError struct {
+	err		error
+}
+
+func (e *unsupportedError) Error() string {
+	return e.err.Error()
+}
+
+func isUnsupported(err error) bool {
+	var unsupported *unsupportedError
+	return errors.As(err, &unsupported)
+}
+
+func encodeJSON(buf *bytes.Buffer, object any) error {
+	js, err := json.Marshal(object)
+	if err != nil {
+		return err
+	}
+	buf.Write(js)
+	return nil
+}
+
+// xmlList wraps top level slices which have no root element otherwise.
+type xmlList struct {
+	XMLName		xml.Name	`xml:"items"`
+	Items		any			`xml:"item"`
+}
+
+func encodeXML(buf *bytes.Buffer, object any) error {
+	if kind := reflect.Indirect(reflect.ValueOf(object)).Kind(); kind == reflect.Slice || kind == reflect.Array {
+		object = &xmlList{ Items: object }
+	}
+
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(buf).Encode(object); err != nil {
+		var unsupported *xml.UnsupportedTypeError
+		if errors.As(err, &unsupported) {
+			return &unsupportedError{ err }
+		}
+		return err
+	}
+	return nil
+}
+
+func encodeCSV(buf *bytes.Buffer, object any) error {
+	table, ok := object.(Table)
+	if !ok {
+		return &unsupportedError{ errors.New("only lists can be rendered as csv") }
+	}
+
+	writer := csv.NewWriter(buf)
+	writer.Write(table.CSVHeader())
+	writer.WriteAll(table.CSVRows())
+	return writer.Error()
+}
+
+func encodeMsgPack(buf *bytes.Buffer, object any) error {
+	encoder := msgpack.NewEncoder(buf)
+	// field names are the same as in JSON
+	encoder.SetCustomStructTag("json")
+	return encoder.Encode(object)
+}
@@ -0,0 +1,55 @@
+package render
+
+import (
+	"testing"
+
+	"songsapi/lyrics"
+)
+
+func TestEncodeSyncedLyricsAsXML(t *testing.T) {
+	lrc, err := lyrics.ParseLRC("[ti:Song]\n[ar:Group]\n[00:01.50]First line\n[00:03.00]Second & last")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, contentType, err := Encode("application/xml", lrc)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if contentType != "application/xml; charset=utf-8" {
+		t.Errorf("content type = %q", contentType)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+		`<LRC><Tags><Tag Name="ar">Group</Tag><Tag Name="ti">Song</Tag></Tags>` +
+		`<Lines><Time>1500</Time><Line>First line</Line></Lines>` +
+		`<Lines><Time>3000</Time><Line>Second &amp; last</Line></Lines></LRC>`
+	if string(body) != want {
+		t.Errorf("body =\n%s\nwant\n%s", body, want)
+	}
+}
+
+func TestEncodeFallsBackForUnsupportedFormats(t *testing.T) {
+	tests := []struct {
+		name		string
+		accept		string
+		wantType	string
+		wantErr		error
+	}{
+		{ "csv only", "text/csv", "", ErrNotAcceptable },
+		{ "csv then json", "text/csv, application/json;q=0.5", "application/json", nil },
+		{ "any", "*/*", "application/json", nil },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, contentType, err := Encode(tt.accept, &lyrics.LRC{ Lines: []lyrics.TimedLine{} })
+			if err != tt.wantErr {
+				t.Fatalf("Encode() error = %v, want %v", err, tt.wantErr)
+			}
+			if contentType != tt.wantType {
+				t.Errorf("content type = %q, want %q", contentType, tt.wantType)
+			}
+		})
+	}
+}
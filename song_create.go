@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"songsapi/logger"
+	"songsapi/storage"
+
+	"github.com/asaskevich/govalidator"
+)
+
+type SongCreateHandler struct {
+	SongsTable 	storage.Storage[storage.Song]
+	GroupsTable storage.Storage[storage.Group]
+}
+
+// SongCreateRequest is a complete song, the group is given by name or id.
+// ReleaseDate has dd.mm.yyyy format like in songs search and info API.
+type SongCreateRequest struct {
+	Song		string	`json:"song" valid:"required"`
+	Group		string	`json:"group"`
+	GroupId		int		`json:"groupId"`
+	ReleaseDate	string	`json:"releaseDate" valid:"date,required"`
+	Text		string	`json:"text" valid:"required"`
+	Link		string	`json:"link" valid:"link,required"`
+	Lang		string	`json:"lang"`
+}
+
+// maximum length of song, group names and link, they are VARCHAR(255) in database
+const maxNameLength = 255
+
+// Validate returns all problems of the request, nil if it is valid.
+func (req *SongCreateRequest) Validate() []string {
+	problems := make([]string, 0)
+	if _, err := govalidator.ValidateStruct(*req); err != nil {
+		if errList, ok := err.(govalidator.Errors); ok {
+			for _, field := range errList.Errors() {
+				problems = append(problems, field.Error())
+			}
+		} else {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if strings.TrimSpace(req.Song) == "" && req.Song != "" {
+		problems = append(problems, "song: must not be blank")
+	}
+	if _, err := time.Parse("02.01.2006", req.ReleaseDate); err != nil && govalidator.IsNotNull(req.ReleaseDate) {
+		problems = append(problems, "releaseDate: not a valid date")
+	}
+
+	switch {
+	case strings.TrimSpace(req.Group) == "" && req.GroupId == 0:
+		problems = append(problems, "group: group or groupId is required")
+	case req.Group != "" && req.GroupId != 0:
+		problems = append(problems, "group: only one of group and groupId can be given")
+	case req.GroupId < 0:
+		problems = append(problems, "groupId: must be positive")
+	}
+
+	for _, field := range []struct{ name, value string }{ { "song", req.Song }, { "group", req.Group }, { "link", req.Link } } {
+		if utf8.RuneCountInString(field.value) > maxNameLength {
+			problems = append(problems, fmt.Sprintf("%s: must be at most %d characters long", field.name, maxNameLength))
+		}
+	}
+
+	if req.Lang != "" && !langRegex.MatchString(req.Lang) {
+		problems = append(problems, "lang: must be a language code like en or pt-br")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+// @Tags songs
+// @Summary Creates song from complete data
+// @Description Unlike /songs/add, the info API isn't called, so any song can be added
+// @Router /songs [post]
+// @Accept json
+// @Produce json
+// @Param request body SongCreateRequest true "Song"
+// @Success 201 {object} storage.Song
+// @Failure 400
+// @Failure 409 {object} SongConflictResponse
+// @Failure 500
+func (h *SongCreateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request SongCreateRequest
+	defer r.Body.Close()
+	if err := PasreJSON(r.Body, &request); err != nil {
+		logger.Err.Println("bad request body - ", err)
+		http.Error(w, "Can't parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if problems := request.Validate(); problems != nil {
+		var buf bytes.Buffer
+		for _, problem := range problems {
+			fmt.Fprintf(&buf, "Validation error in field %v\n", problem)
+		}
+		logger.Err.Println("song didn't pass validation")
+		http.Error(w, buf.String(), http.StatusBadRequest)
+		return
+	}
+
+	group, err := h.group(r.Context(), &request)
+	if err == sql.ErrNoRows {
+		http.Error(w, fmt.Sprintf("Group with id = %d doesn't exist", request.GroupId), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		logger.Err.Println("group resolution failed - ", err)
+		http.Error(w, "Can't find group in database", http.StatusInternalServerError)
+		return
+	}
+
+	releaseDate, _ := time.Parse("02.01.2006", request.ReleaseDate)
+	newSong := storage.Song{
+		Name: strings.TrimSpace(request.Song),
+		Group: group.Name,
+		GroupId: group.Id,
+		ReleaseDate: releaseDate.Format("2006-01-02"),
+		Text: request.Text,
+		Link: request.Link,
+		Lang: strings.ToLower(request.Lang),
+		Status: storage.StatusEnriched,
+	}
+
+	err = h.SongsTable.Create(r.Context(), &newSong)
+	if HandleDuplicateSong(w, r, err) {
+		return
+	}
+	if err != nil {
+		logger.Err.Println("song creation failed - ", err)
+		http.Error(w, "Can't add new song into database", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/songs/%d", newSong.Id))
+	RenderStatus(w, r, http.StatusCreated, &newSong)
+}
+
+func (h *SongCreateHandler) group(ctx context.Context, request *SongCreateRequest) (*storage.Group, error) {
+	if request.GroupId != 0 {
+		return h.GroupsTable.Get(ctx, request.GroupId)
+	}
+	return storage.ResolveGroup(ctx, h.GroupsTable, strings.TrimSpace(request.Group))
+}
@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"songsapi/config"
+	"songsapi/query"
+)
+
+type CacheConfig struct {
+	Size	int
+	TTL		time.Duration
+}
+
+// CacheStats are counters of CachedStorage since the start.
+type CacheStats struct {
+	GetHits			uint64	`json:"getHits"`
+	GetMisses		uint64	`json:"getMisses"`
+	FindHits		uint64	`json:"findHits"`
+	FindMisses		uint64	`json:"findMisses"`
+	Evictions		uint64	`json:"evictions"`
+	Entries			int		`json:"entries"`
+}
+
+// Purger drops everything cached, it is called after writes made around the cache.
+type Purger interface {
+	Purge()
+}
+
+// CachedStorage is a read-through cache of Storage: Get results are kept by id and
+// Find results by normalized query. Writes made through it remove the changed record
+// and all Find results, writes made around it need Purge. Records are copied on the
+// way in and out, so callers can't change cached values.
+type CachedStorage[T any] struct {
+	Storage[T]
+
+	gets		*lru[int, *T]
+	finds		*lru[string, []*T]
+	getHits		atomic.Uint64
+	getMisses	atomic.Uint64
+	findHits	atomic.Uint64
+	findMisses	atomic.Uint64
+
+	// generation grows with every invalidation, reads started before it aren't cached
+	mu			sync.Mutex
+	generation	uint64
+}
+
+var ErrMergeNotSupported = errors.New("storage doesn't support merge")
+
+// CacheConfigFromEnv reads STORAGE_CACHE_SIZE and STORAGE_CACHE_TTL, zero size disables the cache.
+func CacheConfigFromEnv() CacheConfig {
+	return CacheConfig{
+		Size: config.Int("STORAGE_CACHE_SIZE", 1000),
+		TTL: config.Duration("STORAGE_CACHE_TTL", time.Minute),
+	}
+}
+
+func NewCachedStorage[T any](storage Storage[T], cfg CacheConfig) *CachedStorage[T] {
+	return &CachedStorage[T]{
+		Storage: storage,
+		gets: newLRU[int, *T](cfg.Size, cfg.TTL),
+		finds: newLRU[string, []*T](cfg.Size, cfg.TTL),
+	}
+}
+
+func (s *CachedStorage[T]) Get(ctx context.Context, id int) (*T, error) {
+	if cached, ok := s.gets.get(id); ok {
+		s.getHits.Add(1)
+		return copyOf(cached), nil
+	}
+
+	s.getMisses.Add(1)
+	generation := s.currentGeneration()
+	model, err := s.Storage.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.putIfCurrent(generation, func() { s.gets.put(id, copyOf(model)) })
+	return model, nil
+}
+
+func (s *CachedStorage[T]) Find(ctx context.Context, q query.Query) ([]*T, error) {
+	key := queryKey(q)
+	if cached, ok := s.finds.get(key); ok {
+		s.findHits.Add(1)
+		return copyAll(cached), nil
+	}
+
+	s.findMisses.Add(1)
+	generation := s.currentGeneration()
+	models, err := s.Storage.Find(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	s.putIfCurrent(generation, func() { s.finds.put(key, copyAll(models)) })
+	return models, nil
+}
+
+func (s *CachedStorage[T]) Create(ctx context.Context, model *T) error {
+	defer s.invalidate(s.finds.clear)
+	return s.Storage.Create(ctx, model)
+}
+
+func (s *CachedStorage[T]) Update(ctx context.Context, model *T) error {
+	defer s.forget(model)
+	return s.Storage.Update(ctx, model)
+}
+
+func (s *CachedStorage[T]) Delete(ctx context.Context, model *T) error {
+	defer s.forget(model)
+	return s.Storage.Delete(ctx, model)
+}
+
+// Merge passes the call to the wrapped storage if it is a Merger and drops
+// both records, so CachedStorage can be used by merge handlers.
+func (s *CachedStorage[T]) Merge(ctx context.Context, targetId, sourceId int) (*T, error) {
+	merger, ok := s.Storage.(Merger[T])
+	if !ok {
+		return nil, ErrMergeNotSupported
+	}
+
+	defer s.Purge()
+	return merger.Merge(ctx, targetId, sourceId)
+}
+
+func (s *CachedStorage[T]) Purge() {
+	s.invalidate(func() {
+		s.gets.clear()
+		s.finds.clear()
+	})
+}
+
+func (s *CachedStorage[T]) Stats() CacheStats {
+	gets, getEvictions := s.gets.stats()
+	finds, findEvictions := s.finds.stats()
+	return CacheStats{
+		GetHits: s.getHits.Load(),
+		GetMisses: s.getMisses.Load(),
+		FindHits: s.findHits.Load(),
+		FindMisses: s.findMisses.Load(),
+		Evictions: getEvictions + findEvictions,
+		Entries: gets + finds,
+	}
+}
+
+func (s *CachedStorage[T]) forget(model *T) {
+	s.invalidate(func() {
+		if id, ok := modelId(model); ok {
+			s.gets.remove(id)
+		} else {
+			s.gets.clear()
+		}
+		s.finds.clear()
+	})
+}
+
+// invalidate removes records after the write has committed and starts a new generation,
+// so a read which raced the write can't put the old record back.
+func (s *CachedStorage[T]) invalidate(remove func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.generation++
+	remove()
+}
+
+func (s *CachedStorage[T]) currentGeneration() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.generation
+}
+
+// putIfCurrent caches the read only if nothing was invalidated since it started.
+func (s *CachedStorage[T]) putIfCurrent(generation uint64, put func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.generation == generation {
+		put()
+	}
+}
+
+// queryKey builds the same key for queries which produce the same SQL.
+func queryKey(q query.Query) string {
+	if generator, ok := q.(interface{ GenerateSQL() string }); ok {
+		return strings.Join(strings.Fields(generator.GenerateSQL()), " ")
+	}
+	return fmt.Sprintf("%T%+v", q, q)
+}
+
+// modelId reads the Id field of the model, every stored model has one.
+func modelId[T any](model *T) (int, bool) {
+	value := reflect.Indirect(reflect.ValueOf(model))
+	if value.Kind() != reflect.Struct {
+		return 0, false
+	}
+
+	field := value.FieldByName("Id")
+	if !field.IsValid() || field.Kind() != reflect.Int {
+		return 0, false
+	}
+	return int(field.Int()), true
+}
+
+func copyOf[T any](model *T) *T {
+	copied := *model
+	return &copied
+}
+
+func copyAll[T any](models []*T) []*T {
+	copied := make([]*T, len(models))
+	for i, model := range models {
+		copied[i] = copyOf(model)
+	}
+	return copied
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"songsapi/query"
+)
+
+type cachedModel struct {
+	Id		int
+	Name	string
+}
+
+// slowStorage holds the next read until release is closed, so a write can happen in between.
+type slowStorage struct {
+	mu			sync.Mutex
+	name		string
+	reading		chan struct{}
+	release		chan struct{}
+}
+
+func (s *slowStorage) current() *cachedModel {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &cachedModel{ Id: 1, Name: s.name }
+}
+
+func (s *slowStorage) hold() {
+	s.mu.Lock()
+	reading, release := s.reading, s.release
+	s.reading, s.release = nil, nil
+	s.mu.Unlock()
+
+	if reading != nil {
+		close(reading)
+		<-release
+	}
+}
+
+func (s *slowStorage) Get(ctx context.Context, id int) (*cachedModel, error) {
+	model := s.current()
+	s.hold()
+	return model, nil
+}
+
+func (s *slowStorage) Find(ctx context.Context, q query.Query) ([]*cachedModel, error) {
+	model := s.current()
+	s.hold()
+	return []*cachedModel{ model }, nil
+}
+
+func (s *slowStorage) Create(ctx context.Context, model *cachedModel) error {
+	return nil
+}
+
+func (s *slowStorage) Update(ctx context.Context, model *cachedModel) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = model.Name
+	return nil
+}
+
+func (s *slowStorage) Delete(ctx context.Context, model *cachedModel) error {
+	return nil
+}
+
+func TestCachedStorageSkipsReadsRacingWrites(t *testing.T) {
+	get := func(c *CachedStorage[cachedModel]) string {
+		model, _ := c.Get(context.Background(), 1)
+		return model.Name
+	}
+	find := func(c *CachedStorage[cachedModel]) string {
+		models, _ := c.Find(context.Background(), &query.SongQuery{ Name: "test" })
+		return models[0].Name
+	}
+	update := func(c *CachedStorage[cachedModel]) {
+		c.Update(context.Background(), &cachedModel{ Id: 1, Name: "new" })
+	}
+
+	tests := []struct {
+		name	string
+		read	func(c *CachedStorage[cachedModel]) string
+		write	func(c *CachedStorage[cachedModel])
+	}{
+		{ name: "get and update", read: get, write: update },
+		{ name: "find and update", read: find, write: update },
+		{ name: "get and purge", read: get, write: func(c *CachedStorage[cachedModel]) {
+			c.Storage.Update(context.Background(), &cachedModel{ Id: 1, Name: "new" })
+			c.Purge()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slow := &slowStorage{ name: "old", reading: make(chan struct{}), release: make(chan struct{}) }
+			cached := NewCachedStorage[cachedModel](slow, CacheConfig{ Size: 10, TTL: time.Minute })
+
+			raced := make(chan string)
+			reading, release := slow.reading, slow.release
+			go func() { raced <- tt.read(cached) }()
+
+			<-reading
+			tt.write(cached)
+			close(release)
+
+			if name := <-raced; name != "old" {
+				t.Fatalf("raced read returned %q, want the old record", name)
+			}
+			if name := tt.read(cached); name != "new" {
+				t.Errorf("read after the write returned %q, the old record was cached", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"songsapi/logger"
+)
+
+// ChangeMarker tells when any song or group was changed last, deletes included.
+type ChangeMarker interface {
+	LastChangeAt(ctx context.Context) (time.Time, error)
+}
+
+// LastChangeAt reads the time which triggers of songs and groups move with every change.
+func (s *SongStorage) LastChangeAt(ctx context.Context) (time.Time, error) {
+	ctx, done := observe(ctx, "SongStorage", "LastChangeAt")
+	defer done()
+	var changedAt time.Time
+	if err := s.DB.QueryRowContext(ctx, `SELECT "changedAt" FROM catalogue_changes`).Scan(&changedAt); err != nil {
+		logger.Err.Println("can't find the latest change - ", err)
+		return time.Time{}, err
+	}
+
+	return changedAt, nil
+}
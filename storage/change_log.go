@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"songsapi/logger"
+	"time"
+)
+
+// Change is a song or group event saved in the change log, ids grow with every change.
+type Change struct {
+	Id			int64			`json:"id"`
+	Event		string			`json:"event"`
+	Data		json.RawMessage	`json:"data" swaggertype:"object"`
+	CreatedAt	time.Time		`json:"createdAt"`
+}
+
+type ChangeLogStorage interface {
+	ChangesSince(ctx context.Context, afterId int64, limit int) ([]*Change, error)
+	FirstChangeId(ctx context.Context) (int64, error)
+	LastChangeId(ctx context.Context) (int64, error)
+	PruneChanges(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+// ChangeLogTable keeps published events, so change stream clients can resume after reconnect.
+// Inserted rows are announced with NOTIFY on change_log channel.
+type ChangeLogTable struct {
+	DB *sql.DB
+}
+
+func (s *ChangeLogTable) Name() string {
+	return "change_log"
+}
+
+// Publish saves the outbox event into the change log, it is a sink of the outbox relay.
+// Events published again after a relay failure are skipped.
+//
+// Ids are taken under a lock held until commit, so rows become visible in the id order:
+// readers following "id" > last seen never skip a row committed after a higher id.
+func (s *ChangeLogTable) Publish(ctx context.Context, event *OutboxEvent) error {
+	ctx, done := observe(ctx, "ChangeLogTable", "Publish")
+	defer done()
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Err.Println("can't begin transaction - ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('change_log'))`); err != nil {
+		logger.Err.Println("can't lock change_log table - ", err)
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `INSERT INTO change_log ("outboxId", "event", "data", "createdAt") VALUES ($1, $2, $3, $4)
+		ON CONFLICT ("outboxId") DO NOTHING`, event.Id, event.Event, string(event.Data), event.CreatedAt)
+	if err != nil {
+		logger.Err.Println("can't insert into change_log table - ", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Err.Println("can't commit change - ", err)
+		return err
+	}
+	return nil
+}
+
+func (s *ChangeLogTable) ChangesSince(ctx context.Context, afterId int64, limit int) ([]*Change, error) {
+	ctx, done := observe(ctx, "ChangeLogTable", "ChangesSince")
+	defer done()
+	rows, err := s.DB.QueryContext(ctx, `SELECT "id", "event", "data", "createdAt" FROM change_log WHERE "id" > $1 ORDER BY "id" LIMIT $2`,
+							afterId, limit)
+	if err != nil {
+		logger.Err.Println("change log search failed - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	changes := make([]*Change, 0)
+	for rows.Next() {
+		change := Change{}
+		var data string
+		if err := rows.Scan(&change.Id, &change.Event, &data, &change.CreatedAt); err != nil {
+			logger.Err.Println("can't scan change_log row:", err)
+			continue
+		}
+		change.Data = json.RawMessage(data)
+		changes = append(changes, &change)
+	}
+
+	return changes, rows.Err()
+}
+
+// LastChangeId returns id of the latest change, zero for the empty log.
+func (s *ChangeLogTable) LastChangeId(ctx context.Context) (int64, error) {
+	ctx, done := observe(ctx, "ChangeLogTable", "LastChangeId")
+	defer done()
+	var id int64
+	if err := s.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX("id"), 0) FROM change_log`).Scan(&id); err != nil {
+		logger.Err.Println("can't read last change id - ", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// FirstChangeId returns id of the oldest change kept in the log, zero for the empty log.
+func (s *ChangeLogTable) FirstChangeId(ctx context.Context) (int64, error) {
+	ctx, done := observe(ctx, "ChangeLogTable", "FirstChangeId")
+	defer done()
+	var id int64
+	if err := s.DB.QueryRowContext(ctx, `SELECT COALESCE(MIN("id"), 0) FROM change_log`).Scan(&id); err != nil {
+		logger.Err.Println("can't read first change id - ", err)
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// PruneChanges deletes changes older than the given age and returns how many were deleted.
+// The latest change is kept, so the log never forgets how far it went.
+func (s *ChangeLogTable) PruneChanges(ctx context.Context, olderThan time.Duration) (int64, error) {
+	ctx, done := observe(ctx, "ChangeLogTable", "PruneChanges")
+	defer done()
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM change_log WHERE "createdAt" < now() - $1 * interval '1 second'
+		AND "id" < (SELECT MAX("id") FROM change_log)`, olderThan.Seconds())
+	if err != nil {
+		logger.Err.Println("can't delete from change_log table - ", err)
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
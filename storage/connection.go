@@ -1,12 +1,17 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"os"
 	"songsapi/logger"
 
+	"github.com/XSAM/otelsql"
 	_ "github.com/lib/pq"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 
@@ -49,6 +54,17 @@ func createDBIfNotExists(dbName string) error {
 	return nil
 }
 
+// tracedQueries keeps only query spans made within a traced operation, they are children
+// of storage method spans and carry the SQL statement in db.statement attribute.
+var tracedQueries = otelsql.SpanOptions{
+	OmitConnResetSession: true,
+	OmitConnPrepare: true,
+	OmitRows: true,
+	SpanFilter: func(ctx context.Context, method otelsql.Method, query string, args []driver.NamedValue) bool {
+		return trace.SpanContextFromContext(ctx).IsValid()
+	},
+}
+
 func GetDBConnection() *sql.DB {
 	logger.Debug.Println("getting connection to database...")
 	connStr := GetDatabaseURL(true)
@@ -57,7 +73,8 @@ func GetDBConnection() *sql.DB {
 		return nil
 	}
 
-	db, err := sql.Open("postgres", connStr)
+	db, err := otelsql.Open("postgres", connStr, otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+							otelsql.WithSpanOptions(tracedQueries))
 
 	if err != nil {
 		logger.Err.Println("can't establish connection with postgres - ", err)
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"songsapi/logger"
+)
+
+type DuplicateFinder interface {
+	FindDuplicates(ctx context.Context, minSimilarity float64, page, limit int) ([]*DuplicatePair, error)
+}
+
+// DuplicatePair describes two songs which are probably the same track:
+// their names are similar within one group or their lyrics are identical.
+type DuplicatePair struct {
+	First			Song	`json:"first"`
+	Second			Song	`json:"second"`
+	NameSimilarity	float64	`json:"nameSimilarity"`
+	SameLyrics		bool	`json:"sameLyrics"`
+}
+
+// FindDuplicates returns a page of pairs ordered by song ids. Names are only compared inside
+// one group and only when their lengths allow the similarity, so the database doesn't run
+// levenshtein over every pair of the table.
+func (s *SongStorage) FindDuplicates(ctx context.Context, minSimilarity float64, page, limit int) ([]*DuplicatePair, error) {
+	ctx, done := observe(ctx, "SongStorage", "FindDuplicates")
+	defer done()
+	rows, err := s.DB.QueryContext(ctx, `WITH fingerprints AS (
+			SELECT s."id", s."groupId", s."name", g."name" AS "groupName", normalize_title(s."name") AS "title",
+				md5(NULLIF(lower(regexp_replace(s."text", '[^[:alnum:]]+', '', 'g')), '')) AS "lyrics"
+			FROM songs s JOIN "groups" g ON s."groupId" = g."id"
+		), candidates AS (
+			SELECT a."id" AS "firstId", b."id" AS "secondId"
+			FROM fingerprints a JOIN fingerprints b ON a."groupId" = b."groupId" AND a."id" < b."id"
+			WHERE abs(length(a."title") - length(b."title")) <= (1 - $1) * greatest(length(a."title"), length(b."title"))
+				AND title_similarity(a."title", b."title") >= $1
+			UNION
+			SELECT a."id", b."id"
+			FROM fingerprints a JOIN fingerprints b ON a."lyrics" = b."lyrics" AND a."id" < b."id"
+		)
+		SELECT a."id", a."groupId", a."name", a."groupName", b."id", b."groupId", b."name", b."groupName",
+			CASE WHEN a."groupId" = b."groupId" THEN title_similarity(a."title", b."title") ELSE 0 END,
+			COALESCE(a."lyrics" = b."lyrics", false)
+		FROM candidates c
+			JOIN fingerprints a ON a."id" = c."firstId"
+			JOIN fingerprints b ON b."id" = c."secondId"
+		ORDER BY c."firstId", c."secondId"
+		LIMIT $2 OFFSET $3`, minSimilarity, limit, limit * (page - 1))
+	if err != nil {
+		logger.Err.Println("error during duplicates search - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	duplicates := make([]*DuplicatePair, 0, limit)
+	for rows.Next() {
+		pair := DuplicatePair{}
+		if err := rows.Scan(&pair.First.Id, &pair.First.GroupId, &pair.First.Name, &pair.First.Group,
+			&pair.Second.Id, &pair.Second.GroupId, &pair.Second.Name, &pair.Second.Group,
+			&pair.NameSimilarity, &pair.SameLyrics); err != nil {
+			logger.Err.Println("can't scan duplicates row:", err)
+			continue
+		}
+		duplicates = append(duplicates, &pair)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Err.Println("error during duplicates search - ", err)
+		return nil, err
+	}
+
+	return duplicates, nil
+}
+
+// EnsureUniqueNames creates the unique_song_name index which migration 3 skips when songs
+// already repeat each other. It returns how many sets of duplicates still prevent that.
+func (s *SongStorage) EnsureUniqueNames(ctx context.Context) (int, error) {
+	ctx, done := observe(ctx, "SongStorage", "EnsureUniqueNames")
+	defer done()
+	var exists bool
+	if err := s.DB.QueryRowContext(ctx, `SELECT to_regclass('unique_song_name') IS NOT NULL`).Scan(&exists); err != nil || exists {
+		return 0, err
+	}
+
+	var duplicates int
+	err := s.DB.QueryRowContext(ctx, `SELECT count(*) FROM (
+			SELECT 1 FROM songs GROUP BY "groupId", normalize_title("name") HAVING count(*) > 1
+		) duplicates`).Scan(&duplicates)
+	if err != nil || duplicates > 0 {
+		return duplicates, err
+	}
+
+	_, err = s.DB.ExecContext(ctx, `CREATE UNIQUE INDEX IF NOT EXISTS unique_song_name ON songs ("groupId", normalize_title("name"))`)
+	return 0, err
+}
@@ -0,0 +1,23 @@
+package storage
+
+const (
+	EventSongCreated = "song.created"
+	EventSongUpdated = "song.updated"
+	EventSongDeleted = "song.deleted"
+	EventGroupCreated = "group.created"
+	EventGroupUpdated = "group.updated"
+	EventGroupDeleted = "group.deleted"
+	EventGroupMerged = "group.merged"
+)
+
+// GroupMergedEvent is the data of group.merged event, songs moved or merged together
+// with the group get their own song.updated and song.deleted events in the same transaction.
+type GroupMergedEvent struct {
+	Group		*Group	`json:"group"`
+	SourceId	int		`json:"sourceId"`
+}
+
+// DeletedEvent is the data of *.deleted events.
+type DeletedEvent struct {
+	Id		int		`json:"id"`
+}
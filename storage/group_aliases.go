@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"songsapi/logger"
+
+	"github.com/lib/pq"
+)
+
+type GroupAlias struct {
+	Id			int		`json:"id"`
+	GroupId		int		`json:"groupId"`
+	Alias		string	`json:"alias"`
+}
+
+type AliasStorage interface {
+	Aliases(ctx context.Context, groupId int) ([]*GroupAlias, error)
+	AliasesOf(ctx context.Context, groupIds []int) (map[int][]*GroupAlias, error)
+	AddAlias(ctx context.Context, alias *GroupAlias) error
+	DeleteAlias(ctx context.Context, alias *GroupAlias) error
+}
+
+func (s *GroupStorage) Aliases(ctx context.Context, groupId int) ([]*GroupAlias, error) {
+	ctx, done := observe(ctx, "GroupStorage", "Aliases")
+	defer done()
+	rows, err := s.DB.QueryContext(ctx, `SELECT "id", "groupId", "alias" FROM group_aliases WHERE "groupId" = $1 ORDER BY "id"`, groupId)
+	if err != nil {
+		logger.Err.Println("group aliases search failed - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	aliases := make([]*GroupAlias, 0)
+	for rows.Next() {
+		alias := GroupAlias{}
+		if err := rows.Scan(&alias.Id, &alias.GroupId, &alias.Alias); err != nil {
+			logger.Err.Println("can't scan group_aliases row:", err)
+			continue
+		}
+		aliases = append(aliases, &alias)
+	}
+
+	return aliases, nil
+}
+
+// AliasesOf loads aliases of many groups in one query, groups without aliases are missing in the map.
+func (s *GroupStorage) AliasesOf(ctx context.Context, groupIds []int) (map[int][]*GroupAlias, error) {
+	ctx, done := observe(ctx, "GroupStorage", "AliasesOf")
+	defer done()
+	rows, err := s.DB.QueryContext(ctx, `SELECT "id", "groupId", "alias" FROM group_aliases WHERE "groupId" = ANY($1) ORDER BY "id"`, 
+						pq.Array(groupIds))
+	if err != nil {
+		logger.Err.Println("group aliases search failed - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	aliases := make(map[int][]*GroupAlias, len(groupIds))
+	for rows.Next() {
+		alias := GroupAlias{}
+		if err := rows.Scan(&alias.Id, &alias.GroupId, &alias.Alias); err != nil {
+			logger.Err.Println("can't scan group_aliases row:", err)
+			continue
+		}
+		aliases[alias.GroupId] = append(aliases[alias.GroupId], &alias)
+	}
+
+	return aliases, rows.Err()
+}
+
+func (s *GroupStorage) AddAlias(ctx context.Context, alias *GroupAlias) error {
+	ctx, done := observe(ctx, "GroupStorage", "AddAlias")
+	defer done()
+	err := inTx(ctx, s.DB, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `INSERT INTO group_aliases ("groupId", "alias") VALUES ($1, $2) RETURNING "id"`,
+						alias.GroupId, alias.Alias).Scan(&alias.Id)
+		if err != nil {
+			return err
+		}
+		return aliasesChanged(ctx, tx, alias.GroupId)
+	})
+
+	if err != nil {
+		logger.Err.Println("can't insert into group_aliases table - ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *GroupStorage) DeleteAlias(ctx context.Context, alias *GroupAlias) error {
+	ctx, done := observe(ctx, "GroupStorage", "DeleteAlias")
+	defer done()
+	err := inTx(ctx, s.DB, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM group_aliases WHERE "id" = $1 AND "groupId" = $2`, alias.Id, alias.GroupId)
+		if err != nil {
+			return err
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			return sql.ErrNoRows
+		}
+		return aliasesChanged(ctx, tx, alias.GroupId)
+	})
+
+	if err != nil && err != sql.ErrNoRows {
+		logger.Err.Println("can't delete from group_aliases table - ", err)
+	}
+	return err
+}
+
+// aliasesChanged writes group.updated event, since group search matches aliases too.
+func aliasesChanged(ctx context.Context, tx *sql.Tx, groupId int) error {
+	group := Group{}
+	if err := tx.QueryRowContext(ctx, `SELECT id, name FROM groups WHERE id = $1`, groupId).Scan(&group.Id, &group.Name); err != nil {
+		return err
+	}
+	return writeEvent(ctx, tx, AggregateGroup, group.Id, EventGroupUpdated, &group)
+}
@@ -1,10 +1,13 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"songsapi/logger"
 	"songsapi/query"
+
+	"github.com/lib/pq"
 )
 
 type Group struct {
@@ -12,14 +15,17 @@ type Group struct {
 	Name 		string
 }
 
+// GroupStorage writes group.* events into the outbox in the transaction of every change.
 type GroupStorage struct {
 	DB *sql.DB
 }
 
-func (s *GroupStorage) Get(id int) (*Group, error) {
+func (s *GroupStorage) Get(ctx context.Context, id int) (*Group, error) {
+	ctx, done := observe(ctx, "GroupStorage", "Get")
+	defer done()
 	group := Group{}
 
-	if err := s.DB.QueryRow("SELECT * FROM groups WHERE id = $1", id).Scan(&group.Id, &group.Name); err != nil {
+	if err := s.DB.QueryRowContext(ctx, "SELECT * FROM groups WHERE id = $1", id).Scan(&group.Id, &group.Name); err != nil {
 		logger.Err.Println("can't find group with id = ", id)
 		return nil, err
 	}
@@ -27,8 +33,40 @@ func (s *GroupStorage) Get(id int) (*Group, error) {
 	return &group, nil
 }
 
-func (s *GroupStorage) Create(group *Group) error {
-	_, err := s.DB.Exec(`INSERT INTO groups (name) VALUES ($1)`, group.Name)
+func (s *GroupStorage) GetMany(ctx context.Context, ids []int) ([]*Group, error) {
+	ctx, done := observe(ctx, "GroupStorage", "GetMany")
+	defer done()
+	rows, err := s.DB.QueryContext(ctx, `SELECT id, name FROM groups WHERE id = ANY($1) ORDER BY id`, pq.Array(ids))
+	if err != nil {
+		logger.Err.Println("groups search failed - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	groups := make([]*Group, 0, len(ids))
+	for rows.Next() {
+		group := Group{}
+		if err := rows.Scan(&group.Id, &group.Name); err != nil {
+			logger.Err.Println("can't scan groups row:", err)
+			continue
+		}
+		groups = append(groups, &group)
+	}
+
+	return groups, rows.Err()
+}
+
+func (s *GroupStorage) Create(ctx context.Context, group *Group) error {
+	ctx, done := observe(ctx, "GroupStorage", "Create")
+	defer done()
+	err := inTx(ctx, s.DB, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(ctx, `INSERT INTO groups (name) VALUES ($1) RETURNING id`, group.Name).Scan(&group.Id); err != nil {
+			return err
+		}
+		return writeEvent(ctx, tx, AggregateGroup, group.Id, EventGroupCreated, group)
+	})
+
 	if err != nil {
 		logger.Err.Println("can't insert into groups table - ", err)
 		return err
@@ -37,8 +75,43 @@ func (s *GroupStorage) Create(group *Group) error {
 	return nil
 }
 
-func (s *GroupStorage) Delete(group *Group) error {
-	_, err := s.DB.Exec(`DELETE FROM groups WHERE id = $1`, group.Id)
+// Delete removes the group with its songs, every song gets its own song.deleted event.
+func (s *GroupStorage) Delete(ctx context.Context, group *Group) error {
+	ctx, done := observe(ctx, "GroupStorage", "Delete")
+	defer done()
+	err := inTx(ctx, s.DB, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `DELETE FROM songs WHERE "groupId" = $1 RETURNING id`, group.Id)
+		if err != nil {
+			return err
+		}
+
+		songIds := make([]int, 0)
+		for rows.Next() {
+			var songId int
+			if err := rows.Scan(&songId); err != nil {
+				rows.Close()
+				return err
+			}
+			songIds = append(songIds, songId)
+		}
+		rows.Close()
+
+		for _, songId := range songIds {
+			if err := writeEvent(ctx, tx, AggregateSong, songId, EventSongDeleted, &DeletedEvent{ Id: songId }); err != nil {
+				return err
+			}
+		}
+
+		res, err := tx.ExecContext(ctx, `DELETE FROM groups WHERE id = $1`, group.Id)
+		if err != nil {
+			return err
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			return nil
+		}
+		return writeEvent(ctx, tx, AggregateGroup, group.Id, EventGroupDeleted, &DeletedEvent{ Id: group.Id })
+	})
+
 	if err != nil {
 		logger.Err.Println("can't delete from groups table - ", err)
 		return err
@@ -47,8 +120,20 @@ func (s *GroupStorage) Delete(group *Group) error {
 	return nil
 }
 
-func (s *GroupStorage) Update(group *Group) error {
-	_, err := s.DB.Exec(`UPDATE groups SET name = $1 WHERE id = $2`, group.Name, group.Id)
+func (s *GroupStorage) Update(ctx context.Context, group *Group) error {
+	ctx, done := observe(ctx, "GroupStorage", "Update")
+	defer done()
+	err := inTx(ctx, s.DB, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `UPDATE groups SET name = $1 WHERE id = $2`, group.Name, group.Id)
+		if err != nil {
+			return err
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			return nil
+		}
+		return writeEvent(ctx, tx, AggregateGroup, group.Id, EventGroupUpdated, group)
+	})
+
 	if err != nil {
 		logger.Err.Println("can't update groups table - ", err)
 		return err
@@ -57,7 +142,9 @@ func (s *GroupStorage) Update(group *Group) error {
 	return nil
 }
 
-func (s *GroupStorage) Find(q query.Query) ([]*Group, error) {
+func (s *GroupStorage) Find(ctx context.Context, q query.Query) ([]*Group, error) {
+	ctx, done := observe(ctx, "GroupStorage", "Find")
+	defer done()
 	groupQuery, ok := q.(*query.GroupQuery)
 	if !ok {
 		return nil, fmt.Errorf("can't convert search query into groupQuery")
@@ -66,7 +153,15 @@ func (s *GroupStorage) Find(q query.Query) ([]*Group, error) {
 	reserved_capacity := 100
 	groups := make([]*Group, 0, reserved_capacity)
 
-	rows, err := s.DB.Query(`SELECT * FROM groups WHERE name LIKE '%' || $1 || '%'`, groupQuery.Name)
+	searchSQL := `SELECT id, name FROM groups WHERE name LIKE '%' || $1 || '%'
+		OR id IN (SELECT "groupId" FROM group_aliases WHERE "alias" LIKE '%' || $1 || '%') ORDER BY id`
+	if groupQuery.Exact {
+		searchSQL = `SELECT id, name FROM groups WHERE normalize_group_name(name) = normalize_group_name($1)
+			OR id IN (SELECT "groupId" FROM group_aliases WHERE normalize_group_name("alias") = normalize_group_name($1))
+			ORDER BY normalize_title(name) = normalize_title($1) DESC, id`
+	}
+
+	rows, err := s.DB.QueryContext(ctx, searchSQL, groupQuery.Name)
 
 	if err != nil {
 		logger.Err.Println("groups search failed - ", err)
@@ -85,4 +180,37 @@ func (s *GroupStorage) Find(q query.Query) ([]*Group, error) {
 	}
 
 	return groups, nil
-}
\ No newline at end of file
+}
+
+// ResolveGroup finds the group by its name or one of its aliases, 
+// the group is created only when nothing matches.
+func ResolveGroup(ctx context.Context, groupsTable Storage[Group], name string) (*Group, error) {
+	groupQuery := &query.GroupQuery{ Name: name, Exact: true }
+	groups, err := groupsTable.Find(ctx, groupQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groups) > 0 {
+		return groups[0], nil
+	}
+
+	err = groupsTable.Create(ctx, &Group{ Name: name })
+	if err != nil {
+		pgErr, ok := err.(*pq.Error)
+		if !(ok && pgErr.Code == "23505") {
+			return nil, err
+		}
+	}
+
+	groups, err = groupsTable.Find(ctx, groupQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	return groups[0], nil
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"songsapi/logger"
+	"time"
+)
+
+// CachedInfo is an info API response saved for the song, ReleaseDate keeps dd.mm.yyyy format.
+type CachedInfo struct {
+	Id			int			`json:"id"`
+	Group		string		`json:"group"`
+	Song		string		`json:"song"`
+	ReleaseDate	string		`json:"releaseDate"`
+	Text		string		`json:"text"`
+	Link		string		`json:"link"`
+	FetchedAt	time.Time	`json:"fetchedAt"`
+}
+
+type InfoCacheStorage interface {
+	CachedInfo(ctx context.Context, group, song string) (*CachedInfo, error)
+	SaveInfo(ctx context.Context, info *CachedInfo) error
+	ListCachedInfo(ctx context.Context, group, song string, limit, offset int) ([]*CachedInfo, error)
+	DeleteCachedInfo(ctx context.Context, id int) error
+	InvalidateInfo(ctx context.Context, group, song string) (int64, error)
+}
+
+const cachedInfoColumns = `"id", "group", "song", "releaseDate", "text", "link", "fetchedAt"`
+
+func scanCachedInfo(scan func(dest ...any) error) (*CachedInfo, error) {
+	info := CachedInfo{}
+	err := scan(&info.Id, &info.Group, &info.Song, &info.ReleaseDate, &info.Text, &info.Link, &info.FetchedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+type InfoCacheTable struct {
+	DB *sql.DB
+}
+
+// CachedInfo finds the entry by normalized group and song names, sql.ErrNoRows means a miss.
+func (s *InfoCacheTable) CachedInfo(ctx context.Context, group, song string) (*CachedInfo, error) {
+	ctx, done := observe(ctx, "InfoCacheTable", "CachedInfo")
+	defer done()
+	info, err := scanCachedInfo(s.DB.QueryRowContext(ctx, `SELECT ` + cachedInfoColumns + ` FROM song_info_cache
+		WHERE normalize_group_name("group") = normalize_group_name($1) AND normalize_title("song") = normalize_title($2)`,
+		group, song).Scan)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.Err.Println("info cache search failed - ", err)
+		}
+		return nil, err
+	}
+
+	return info, nil
+}
+
+func (s *InfoCacheTable) SaveInfo(ctx context.Context, info *CachedInfo) error {
+	ctx, done := observe(ctx, "InfoCacheTable", "SaveInfo")
+	defer done()
+	err := s.DB.QueryRowContext(ctx, `INSERT INTO song_info_cache ("group", "song", "releaseDate", "text", "link") VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (normalize_group_name("group"), normalize_title("song")) DO UPDATE SET "releaseDate" = EXCLUDED."releaseDate",
+			"text" = EXCLUDED."text", "link" = EXCLUDED."link", "fetchedAt" = now()
+		RETURNING "id", "fetchedAt"`, info.Group, info.Song, info.ReleaseDate, info.Text, info.Link).Scan(&info.Id, &info.FetchedAt)
+	if err != nil {
+		logger.Err.Println("can't save into song_info_cache table - ", err)
+		return err
+	}
+
+	return nil
+}
+
+// ListCachedInfo returns the latest entries, group and song are optional substrings of the names.
+func (s *InfoCacheTable) ListCachedInfo(ctx context.Context, group, song string, limit, offset int) ([]*CachedInfo, error) {
+	ctx, done := observe(ctx, "InfoCacheTable", "ListCachedInfo")
+	defer done()
+	rows, err := s.DB.QueryContext(ctx, `SELECT ` + cachedInfoColumns + ` FROM song_info_cache
+		WHERE normalize_group_name("group") LIKE '%' || normalize_group_name($1) || '%'
+			AND normalize_title("song") LIKE '%' || normalize_title($2) || '%'
+		ORDER BY "fetchedAt" DESC, "id" DESC LIMIT $3 OFFSET $4`, group, song, limit, offset)
+	if err != nil {
+		logger.Err.Println("info cache search failed - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entries := make([]*CachedInfo, 0)
+	for rows.Next() {
+		info, err := scanCachedInfo(rows.Scan)
+		if err != nil {
+			logger.Err.Println("can't scan song_info_cache row:", err)
+			continue
+		}
+		entries = append(entries, info)
+	}
+
+	return entries, rows.Err()
+}
+
+// DeleteCachedInfo removes the entry by id, sql.ErrNoRows means there is no such entry.
+func (s *InfoCacheTable) DeleteCachedInfo(ctx context.Context, id int) error {
+	ctx, done := observe(ctx, "InfoCacheTable", "DeleteCachedInfo")
+	defer done()
+	result, err := s.DB.ExecContext(ctx, `DELETE FROM song_info_cache WHERE "id" = $1`, id)
+	if err != nil {
+		logger.Err.Println("can't delete from song_info_cache table - ", err)
+		return err
+	}
+
+	if deleted, _ := result.RowsAffected(); deleted == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// InvalidateInfo removes entries of the group, or only of its song if song isn't empty.
+// Empty group removes the whole cache.
+func (s *InfoCacheTable) InvalidateInfo(ctx context.Context, group, song string) (int64, error) {
+	ctx, done := observe(ctx, "InfoCacheTable", "InvalidateInfo")
+	defer done()
+	result, err := s.DB.ExecContext(ctx, `DELETE FROM song_info_cache
+		WHERE ($1 = '' OR normalize_group_name("group") = normalize_group_name($1))
+			AND ($2 = '' OR normalize_title("song") = normalize_title($2))`, group, song)
+	if err != nil {
+		logger.Err.Println("can't delete from song_info_cache table - ", err)
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
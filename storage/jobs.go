@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"songsapi/logger"
+	"time"
+)
+
+const (
+	JobQueued = "queued"
+	JobRunning = "running"
+	JobRetrying = "retrying"
+	JobDone = "done"
+	JobFailed = "failed"
+)
+
+// Job is a background enrichment of the song with info API data.
+type Job struct {
+	Id			int			`json:"id"`
+	SongId		int			`json:"songId"`
+	Status		string		`json:"status"`
+	Attempts	int			`json:"attempts"`
+	LastError	string		`json:"lastError,omitempty"`
+	RunAt		time.Time	`json:"runAt"`
+	CreatedAt	time.Time	`json:"createdAt"`
+	UpdatedAt	time.Time	`json:"updatedAt"`
+}
+
+type JobStorage interface {
+	CreateJob(ctx context.Context, song *Song) (*Job, error)
+	GetJob(ctx context.Context, id int) (*Job, error)
+	ClaimJob(ctx context.Context, lease time.Duration) (*Job, error)
+	FinishJob(ctx context.Context, job *Job, retryAfter time.Duration) error
+}
+
+type JobsTable struct {
+	DB *sql.DB
+}
+
+const jobColumns = `"id", "songId", "status", "attempts", "lastError", "runAt", "createdAt", "updatedAt"`
+
+func scanJob(row *sql.Row) (*Job, error) {
+	job := Job{}
+	err := row.Scan(&job.Id, &job.SongId, &job.Status, &job.Attempts, &job.LastError, &job.RunAt, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// CreateJob saves the song as pending enrichment together with its job in one transaction,
+// so there is no pending song which no job is going to enrich.
+func (s *JobsTable) CreateJob(ctx context.Context, song *Song) (*Job, error) {
+	ctx, done := observe(ctx, "JobsTable", "CreateJob")
+	defer done()
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Err.Println("can't begin transaction - ", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `INSERT INTO songs ("groupId", "name", "releaseDate", "text", "link", "lang", "status") 
+						VALUES ($1, $2, NULLIF($3, '')::date, $4, $5, $6, $7) RETURNING "id", "updatedAt"`, 
+						song.GroupId, song.Name, song.ReleaseDate, song.Text, song.Link, song.Lang, StatusPendingEnrichment).Scan(
+						&song.Id, &song.UpdatedAt)
+	if err != nil {
+		if isSongNameViolation(err) {
+			songs := SongStorage{ DB: s.DB }
+			return nil, songs.duplicateOf(ctx, song)
+		}
+		logger.Err.Println("can't insert into songs table - ", err)
+		return nil, err
+	}
+	song.Status = StatusPendingEnrichment
+
+	job, err := scanJob(tx.QueryRowContext(ctx, `INSERT INTO enrichment_jobs ("songId") VALUES ($1) RETURNING ` + jobColumns, song.Id))
+	if err != nil {
+		logger.Err.Println("can't insert into enrichment_jobs table - ", err)
+		return nil, err
+	}
+
+	if err := writeEvent(ctx, tx, AggregateSong, song.Id, EventSongCreated, song); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Err.Println("can't commit song with enrichment job - ", err)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+func (s *JobsTable) GetJob(ctx context.Context, id int) (*Job, error) {
+	ctx, done := observe(ctx, "JobsTable", "GetJob")
+	defer done()
+	job, err := scanJob(s.DB.QueryRowContext(ctx, `SELECT ` + jobColumns + ` FROM enrichment_jobs WHERE "id" = $1`, id))
+	if err != nil {
+		logger.Err.Println("can't find job with id = ", id)
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// ClaimJob marks the oldest runnable job as running for the lease duration, so it isn't
+// taken by other workers or replicas. Running jobs with expired lease are taken again,
+// that's how jobs of a crashed process are recovered. sql.ErrNoRows means nothing to do.
+func (s *JobsTable) ClaimJob(ctx context.Context, lease time.Duration) (*Job, error) {
+	ctx, done := observe(ctx, "JobsTable", "ClaimJob")
+	defer done()
+	job, err := scanJob(s.DB.QueryRowContext(ctx, `UPDATE enrichment_jobs SET "status" = 'running', "attempts" = "attempts" + 1,
+		"runAt" = now() + $1 * interval '1 millisecond', "updatedAt" = now()
+		WHERE "id" = (SELECT "id" FROM enrichment_jobs WHERE "status" IN ('queued', 'retrying', 'running') AND "runAt" <= now()
+			ORDER BY "runAt" FOR UPDATE SKIP LOCKED LIMIT 1)
+		RETURNING ` + jobColumns, lease.Milliseconds()))
+	if err != nil && err != sql.ErrNoRows {
+		logger.Err.Println("can't claim enrichment job - ", err)
+	}
+
+	return job, err
+}
+
+// FinishJob saves the job status and error, retrying jobs are run again after retryAfter.
+func (s *JobsTable) FinishJob(ctx context.Context, job *Job, retryAfter time.Duration) error {
+	ctx, done := observe(ctx, "JobsTable", "FinishJob")
+	defer done()
+	err := s.DB.QueryRowContext(ctx, `UPDATE enrichment_jobs SET "status" = $1, "lastError" = $2,
+		"runAt" = now() + $3 * interval '1 millisecond', "updatedAt" = now() WHERE "id" = $4 RETURNING "runAt", "updatedAt"`,
+		job.Status, job.LastError, retryAfter.Milliseconds(), job.Id).Scan(&job.RunAt, &job.UpdatedAt)
+	if err != nil {
+		logger.Err.Println("can't update enrichment_jobs table - ", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lru is a size limited map which evicts least recently used entries,
+// entries older than ttl are treated as missing. Zero ttl means entries never expire.
+type lru[K comparable, V any] struct {
+	mu			sync.Mutex
+	size		int
+	ttl			time.Duration
+	order		*list.List
+	items		map[K]*list.Element
+	evictions	uint64
+}
+
+type lruEntry[K comparable, V any] struct {
+	key			K
+	value		V
+	storedAt	time.Time
+}
+
+func newLRU[K comparable, V any](size int, ttl time.Duration) *lru[K, V] {
+	return &lru[K, V]{ size: size, ttl: ttl, order: list.New(), items: make(map[K]*list.Element) }
+}
+
+func (c *lru[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	entry := elem.Value.(*lruEntry[K, V])
+	if c.ttl > 0 && time.Since(entry.storedAt) > c.ttl {
+		c.order.Remove(elem)
+		delete(c.items, key)
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (c *lru[K, V]) put(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value = &lruEntry[K, V]{ key: key, value: value, storedAt: time.Now() }
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{ key: key, value: value, storedAt: time.Now() })
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[K, V]).key)
+		c.evictions++
+	}
+}
+
+func (c *lru[K, V]) remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.order.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+func (c *lru[K, V]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.items = make(map[K]*list.Element)
+}
+
+func (c *lru[K, V]) stats() (int, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len(), c.evictions
+}
@@ -0,0 +1,247 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"songsapi/logger"
+
+	"github.com/lib/pq"
+)
+
+var ErrSelfMerge = errors.New("can't merge record into itself")
+
+// Merger folds the source record into the target one and removes the source.
+type Merger[T any] interface {
+	Merge(ctx context.Context, targetId, sourceId int) (*T, error)
+}
+
+const fillMissingSongFields = `UPDATE songs t SET
+	"releaseDate" = COALESCE(t."releaseDate", s."releaseDate"),
+	"text" = COALESCE(NULLIF(t."text", ''), s."text"),
+	"link" = COALESCE(NULLIF(t."link", ''), s."link"),
+	"lang" = COALESCE(NULLIF(t."lang", ''), s."lang")`
+
+func (s *SongStorage) Merge(ctx context.Context, targetId, sourceId int) (*Song, error) {
+	ctx, done := observe(ctx, "SongStorage", "Merge")
+	defer done()
+	if targetId == sourceId {
+		return nil, ErrSelfMerge
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Err.Println("can't begin songs merge transaction - ", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, fillMissingSongFields + ` FROM songs s WHERE t.id = $1 AND s.id = $2`, targetId, sourceId)
+	if err != nil {
+		logger.Err.Println("can't merge songs - ", err)
+		return nil, err
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		logger.Err.Printf("can't find songs to merge: target = %d, source = %d\n", targetId, sourceId)
+		return nil, sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO song_synced_lyrics ("songId", "lrc", "updatedAt")
+		SELECT $1, "lrc", "updatedAt" FROM song_synced_lyrics WHERE "songId" = $2 ON CONFLICT DO NOTHING`, targetId, sourceId)
+	if err != nil {
+		logger.Err.Println("can't merge synced lyrics - ", err)
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO song_translations ("songId", "lang", "text", "translator")
+		SELECT $1, "lang", "text", "translator" FROM song_translations WHERE "songId" = $2 ON CONFLICT DO NOTHING`, targetId, sourceId)
+	if err != nil {
+		logger.Err.Println("can't merge song translations - ", err)
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM songs WHERE id = $1`, sourceId); err != nil {
+		logger.Err.Println("can't delete merged song - ", err)
+		return nil, err
+	}
+
+	target := Song{}
+	var releaseDate, link sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT "id", "groupId", "name", "releaseDate", "text", "link", "lang", "status", "updatedAt" FROM songs
+		WHERE id = $1`, targetId).Scan(&target.Id, &target.GroupId, &target.Name, &releaseDate, &target.Text, &link, &target.Lang,
+		&target.Status, &target.UpdatedAt)
+	if err != nil {
+		logger.Err.Println("can't find merged song - ", err)
+		return nil, err
+	}
+	target.ReleaseDate, target.Link = releaseDate.String, link.String
+
+	if err := writeEvent(ctx, tx, AggregateSong, sourceId, EventSongDeleted, &DeletedEvent{ Id: sourceId }); err != nil {
+		return nil, err
+	}
+	if err := writeEvent(ctx, tx, AggregateSong, targetId, EventSongUpdated, &target); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Err.Println("can't commit songs merge - ", err)
+		return nil, err
+	}
+
+	return s.Get(ctx, targetId)
+}
+
+func (s *GroupStorage) Merge(ctx context.Context, targetId, sourceId int) (*Group, error) {
+	ctx, done := observe(ctx, "GroupStorage", "Merge")
+	defer done()
+	if targetId == sourceId {
+		return nil, ErrSelfMerge
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Err.Println("can't begin groups merge transaction - ", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	target, source := Group{}, Group{}
+	if err := tx.QueryRowContext(ctx, `SELECT id, name FROM groups WHERE id = $1 FOR UPDATE`, targetId).Scan(&target.Id, &target.Name); err != nil {
+		logger.Err.Println("can't find group with id = ", targetId)
+		return nil, err
+	}
+	if err := tx.QueryRowContext(ctx, `SELECT id, name FROM groups WHERE id = $1 FOR UPDATE`, sourceId).Scan(&source.Id, &source.Name); err != nil {
+		logger.Err.Println("can't find group with id = ", sourceId)
+		return nil, err
+	}
+
+	// songs present in both groups are merged song by song, the rest are simply moved
+	mergedIds, err := queryIds(ctx, tx, fillMissingSongFields + ` FROM songs s WHERE t."groupId" = $1 AND s."groupId" = $2
+		AND normalize_title(t."name") = normalize_title(s."name") RETURNING t.id`, targetId, sourceId)
+	if err != nil {
+		logger.Err.Println("can't merge groups - ", err)
+		return nil, err
+	}
+
+	statements := []string{
+		`INSERT INTO song_synced_lyrics ("songId", "lrc", "updatedAt")
+			SELECT t.id, l."lrc", l."updatedAt" FROM songs t
+			JOIN songs s ON normalize_title(t."name") = normalize_title(s."name")
+			JOIN song_synced_lyrics l ON l."songId" = s.id
+			WHERE t."groupId" = $1 AND s."groupId" = $2 ON CONFLICT DO NOTHING`,
+		`INSERT INTO song_translations ("songId", "lang", "text", "translator")
+			SELECT t.id, tr."lang", tr."text", tr."translator" FROM songs t
+			JOIN songs s ON normalize_title(t."name") = normalize_title(s."name")
+			JOIN song_translations tr ON tr."songId" = s.id
+			WHERE t."groupId" = $1 AND s."groupId" = $2 ON CONFLICT DO NOTHING`,
+		`UPDATE group_aliases SET "groupId" = $1 WHERE "groupId" = $2`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement, targetId, sourceId); err != nil {
+			logger.Err.Println("can't merge groups - ", err)
+			return nil, err
+		}
+	}
+
+	deletedIds, err := queryIds(ctx, tx, `DELETE FROM songs s USING songs t WHERE t."groupId" = $1 AND s."groupId" = $2
+		AND normalize_title(t."name") = normalize_title(s."name") RETURNING s.id`, targetId, sourceId)
+	if err != nil {
+		logger.Err.Println("can't merge groups - ", err)
+		return nil, err
+	}
+
+	movedIds, err := queryIds(ctx, tx, `UPDATE songs SET "groupId" = $1 WHERE "groupId" = $2 RETURNING id`, targetId, sourceId)
+	if err != nil {
+		logger.Err.Println("can't merge groups - ", err)
+		return nil, err
+	}
+
+	for _, songId := range deletedIds {
+		if err := writeEvent(ctx, tx, AggregateSong, songId, EventSongDeleted, &DeletedEvent{ Id: songId }); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := songsByIds(ctx, tx, append(mergedIds, movedIds...))
+	if err != nil {
+		logger.Err.Println("can't find merged songs - ", err)
+		return nil, err
+	}
+	for _, song := range updated {
+		song.Group = target.Name
+		if err := writeEvent(ctx, tx, AggregateSong, song.Id, EventSongUpdated, song); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM groups WHERE id = $1`, sourceId); err != nil {
+		logger.Err.Println("can't delete merged group - ", err)
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO group_aliases ("groupId", "alias") VALUES ($1, $2) ON CONFLICT DO NOTHING`, targetId, source.Name)
+	if err != nil {
+		logger.Err.Println("can't insert into group_aliases table - ", err)
+		return nil, err
+	}
+
+	err = writeEvent(ctx, tx, AggregateGroup, targetId, EventGroupMerged, &GroupMergedEvent{ Group: &target, SourceId: sourceId })
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Err.Println("can't commit groups merge - ", err)
+		return nil, err
+	}
+
+	return &target, nil
+}
+
+// queryIds runs the statement returning ids of the affected rows.
+func queryIds(ctx context.Context, tx *sql.Tx, query string, args ...any) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// songsByIds reads the songs as they are seen inside the transaction, in the id order.
+func songsByIds(ctx context.Context, tx *sql.Tx, ids []int) ([]*Song, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT "id", "groupId", "name", "releaseDate", "text", "link", "lang", "status", "updatedAt" FROM songs
+		WHERE id = ANY($1) ORDER BY id`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	songs := make([]*Song, 0, len(ids))
+	for rows.Next() {
+		song := Song{}
+		var releaseDate, link sql.NullString
+		err := rows.Scan(&song.Id, &song.GroupId, &song.Name, &releaseDate, &song.Text, &link, &song.Lang, &song.Status, &song.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		song.ReleaseDate, song.Link = releaseDate.String, link.String
+		songs = append(songs, &song)
+	}
+
+	return songs, rows.Err()
+}
@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"songsapi/logger"
+	"time"
+)
+
+// MetadataChange is a song field changed by metadata refresh.
+type MetadataChange struct {
+	Id			int			`json:"id"`
+	SongId		int			`json:"songId"`
+	Field		string		`json:"field"`
+	OldValue	string		`json:"oldValue"`
+	NewValue	string		`json:"newValue"`
+	ChangedAt	time.Time	`json:"changedAt"`
+}
+
+type MetadataRefreshStorage interface {
+	StaleSongs(ctx context.Context, maxAge, incompleteAge time.Duration, limit int) ([]*Song, error)
+	RefreshSong(ctx context.Context, song *Song, changes []*MetadataChange) error
+	MetadataChanges(ctx context.Context, songId, limit int) ([]*MetadataChange, error)
+}
+
+// StaleSongs returns enriched songs which metadata is older than maxAge, or older than
+// incompleteAge when link or text is missing. Songs never refreshed go first.
+func (s *SongStorage) StaleSongs(ctx context.Context, maxAge, incompleteAge time.Duration, limit int) ([]*Song, error) {
+	ctx, done := observe(ctx, "SongStorage", "StaleSongs")
+	defer done()
+	rows, err := s.DB.QueryContext(ctx, `SELECT s."id", s."groupId", s."name", s."releaseDate", s."text", s."link", s."lang", s."status",
+		s."enrichedAt", g."name" FROM songs s JOIN "groups" g ON s."groupId" = g."id"
+		WHERE s."status" <> 'pending_enrichment' AND (s."enrichedAt" IS NULL
+			OR s."enrichedAt" < now() - $1 * interval '1 second'
+			OR ((COALESCE(s."link", '') = '' OR s."text" = '') AND s."enrichedAt" < now() - $2 * interval '1 second'))
+		ORDER BY s."enrichedAt" NULLS FIRST LIMIT $3`, maxAge.Seconds(), incompleteAge.Seconds(), limit)
+	if err != nil {
+		logger.Err.Println("stale songs search failed - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	songs := make([]*Song, 0, limit)
+	for rows.Next() {
+		song := Song{}
+		var releaseDate, link sql.NullString
+		var enrichedAt sql.NullTime
+		err := rows.Scan(&song.Id, &song.GroupId, &song.Name, &releaseDate, &song.Text, &link, &song.Lang, &song.Status,
+						&enrichedAt, &song.Group)
+		if err != nil {
+			logger.Err.Println("can't scan songs table row:", err)
+			continue
+		}
+		song.ReleaseDate, song.Link = releaseDate.String, link.String
+		if enrichedAt.Valid {
+			song.EnrichedAt = &enrichedAt.Time
+		}
+		songs = append(songs, &song)
+	}
+
+	return songs, rows.Err()
+}
+
+// RefreshSong saves the refreshed song the same way as Update and records its changes in one transaction.
+// Without changes only "enrichedAt" is moved, so the song isn't checked again until it is stale
+// while for everyone else the song stays the same.
+func (s *SongStorage) RefreshSong(ctx context.Context, song *Song, changes []*MetadataChange) error {
+	ctx, done := observe(ctx, "SongStorage", "RefreshSong")
+	defer done()
+	if len(changes) == 0 {
+		_, err := s.DB.ExecContext(ctx, `UPDATE songs SET "enrichedAt" = $1 WHERE "id" = $2`, song.EnrichedAt, song.Id)
+		if err != nil {
+			logger.Err.Println("can't update songs table - ", err)
+		}
+		return err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Err.Println("can't begin transaction - ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := updateSong(ctx, tx, song); err != nil {
+		logger.Err.Println("can't update songs table - ", err)
+		return err
+	}
+
+	for _, change := range changes {
+		err := tx.QueryRowContext(ctx, `INSERT INTO song_metadata_changes ("songId", "field", "oldValue", "newValue") VALUES ($1, $2, $3, $4)
+							RETURNING "id", "changedAt"`, change.SongId, change.Field, change.OldValue, change.NewValue).Scan(
+			&change.Id, &change.ChangedAt)
+		if err != nil {
+			logger.Err.Println("can't insert into song_metadata_changes table - ", err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Err.Println("can't commit refreshed song - ", err)
+		return err
+	}
+
+	return nil
+}
+
+// MetadataChanges returns the latest changes, zero songId means changes of all songs.
+func (s *SongStorage) MetadataChanges(ctx context.Context, songId, limit int) ([]*MetadataChange, error) {
+	ctx, done := observe(ctx, "SongStorage", "MetadataChanges")
+	defer done()
+	rows, err := s.DB.QueryContext(ctx, `SELECT "id", "songId", "field", "oldValue", "newValue", "changedAt" FROM song_metadata_changes
+							WHERE $1 = 0 OR "songId" = $1 ORDER BY "id" DESC LIMIT $2`, songId, limit)
+	if err != nil {
+		logger.Err.Println("metadata changes search failed - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	changes := make([]*MetadataChange, 0)
+	for rows.Next() {
+		change := MetadataChange{}
+		if err := rows.Scan(&change.Id, &change.SongId, &change.Field, &change.OldValue, &change.NewValue, &change.ChangedAt); err != nil {
+			logger.Err.Println("can't scan song_metadata_changes row:", err)
+			continue
+		}
+		changes = append(changes, &change)
+	}
+
+	return changes, nil
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"os"
 	"songsapi/logger"
 
@@ -38,6 +39,11 @@ func (migrator *Migrator) MakeMigrations() error {
 			return nil
 		}
 
+		var dirty migrate.ErrDirty
+		if errors.As(err, &dirty) {
+			logger.Err.Printf("migration %d failed earlier and left the schema dirty, fix it and run `migrate force %d`\n",
+				dirty.Version, dirty.Version - 1)
+		}
 		logger.Err.Println("migration failed - ", err)
 		return err
 	}
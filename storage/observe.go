@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// MethodObserver receives the duration of every storage method call, it is set by metrics at start.
+var MethodObserver func(storage, method string, took time.Duration)
+
+var tracer = otel.Tracer("songsapi/storage")
+
+// observe starts the span of the storage method, queries made with the returned context
+// become its children. The returned func ends the span and reports the duration to MethodObserver.
+// Calls outside of a traced operation, like polls of background workers, get no span.
+func observe(ctx context.Context, storage, method string) (context.Context, func()) {
+	start := time.Now()
+	var span trace.Span
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		ctx, span = tracer.Start(ctx, storage + "." + method, trace.WithAttributes(
+			attribute.String("code.namespace", "songsapi/storage." + storage),
+			attribute.String("code.function", method),
+		))
+	}
+
+	return ctx, func() {
+		if span != nil {
+			span.End()
+		}
+		if MethodObserver != nil {
+			MethodObserver(storage, method, time.Since(start))
+		}
+	}
+}
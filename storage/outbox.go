@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"songsapi/logger"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	AggregateSong = "song"
+	AggregateGroup = "group"
+)
+
+const (
+	OutboxSending = "sending"
+	OutboxRetrying = "retrying"
+	OutboxPublished = "published"
+	OutboxDead = "dead"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the change it describes.
+// Status, Attempts and LastError describe its delivery to the sink it was claimed for.
+type OutboxEvent struct {
+	Id				int64			`json:"id"`
+	AggregateType	string			`json:"aggregateType"`
+	AggregateId		int				`json:"aggregateId"`
+	Event			string			`json:"event"`
+	Data			json.RawMessage	`json:"data" swaggertype:"object"`
+	CreatedAt		time.Time		`json:"createdAt"`
+	Status			string			`json:"-"`
+	Attempts		int				`json:"-"`
+	LastError		string			`json:"-"`
+}
+
+type OutboxStorage interface {
+	ClaimOutbox(ctx context.Context, sink string, limit int, lease time.Duration) ([]*OutboxEvent, error)
+	FinishOutbox(ctx context.Context, sink string, event *OutboxEvent, retryAfter time.Duration) error
+	CompleteOutbox(ctx context.Context, sinks []string) (int64, error)
+	PruneOutbox(ctx context.Context, olderThan time.Duration) (int64, error)
+}
+
+type OutboxTable struct {
+	DB *sql.DB
+}
+
+// claimLock serializes claims of the sink between replicas, it is held only by the claim transaction.
+const claimLock = `SELECT pg_try_advisory_xact_lock(hashtext('outbox_relay:' || $1::text))`
+
+// ClaimOutbox marks events due for the sink as sending for the lease duration, the same
+// way as ClaimJob does, and returns them in the id order. An event is due when the sink
+// hasn't tried it yet or its retry time has come, and every earlier event of its aggregate
+// is published to the sink or dead, so the sink gets events of an aggregate in order.
+// sql.ErrNoRows means there's nothing to publish or another replica is claiming.
+func (s *OutboxTable) ClaimOutbox(ctx context.Context, sink string, limit int, lease time.Duration) ([]*OutboxEvent, error) {
+	ctx, done := observe(ctx, "OutboxTable", "ClaimOutbox")
+	defer done()
+	var events []*OutboxEvent
+	err := inTx(ctx, s.DB, func(tx *sql.Tx) error {
+		var locked bool
+		if err := tx.QueryRowContext(ctx, claimLock, sink).Scan(&locked); err != nil || !locked {
+			if err == nil {
+				err = sql.ErrNoRows
+			}
+			return err
+		}
+
+		rows, err := tx.QueryContext(ctx, `WITH due AS (
+				SELECT o."id" FROM outbox o
+				LEFT JOIN outbox_deliveries d ON d."outboxId" = o."id" AND d."sink" = $1::text
+				WHERE o."publishedAt" IS NULL
+					AND (d."status" IS NULL OR (d."status" IN ('sending', 'retrying') AND d."nextAttemptAt" <= now()))
+					AND NOT EXISTS (SELECT 1 FROM outbox e
+						LEFT JOIN outbox_deliveries f ON f."outboxId" = e."id" AND f."sink" = $1::text
+						WHERE e."aggregateType" = o."aggregateType" AND e."aggregateId" = o."aggregateId" AND e."id" < o."id"
+							AND e."publishedAt" IS NULL AND (f."status" IS NULL OR f."status" IN ('sending', 'retrying')))
+				ORDER BY o."id" LIMIT $2::int
+			), claimed AS (
+				INSERT INTO outbox_deliveries ("outboxId", "sink", "status", "attempts", "nextAttemptAt")
+				SELECT "id", $1::text, 'sending', 1, now() + $3 * interval '1 millisecond' FROM due
+				ON CONFLICT ("outboxId", "sink") DO UPDATE SET "status" = 'sending', "attempts" = outbox_deliveries."attempts" + 1,
+					"nextAttemptAt" = EXCLUDED."nextAttemptAt"
+				RETURNING "outboxId", "attempts"
+			)
+			SELECT o."id", o."aggregateType", o."aggregateId", o."event", o."data", o."createdAt", c."attempts"
+			FROM claimed c JOIN outbox o ON o."id" = c."outboxId" ORDER BY o."id"`, sink, limit, lease.Milliseconds())
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			event := OutboxEvent{ Status: OutboxSending }
+			var data string
+			err := rows.Scan(&event.Id, &event.AggregateType, &event.AggregateId, &event.Event, &data, &event.CreatedAt, &event.Attempts)
+			if err != nil {
+				return err
+			}
+			event.Data = json.RawMessage(data)
+			events = append(events, &event)
+		}
+
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return sql.ErrNoRows
+		}
+		return nil
+	})
+
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logger.Err.Printf("can't claim outbox events for %s sink - %v\n", sink, err)
+		}
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// FinishOutbox saves the result of the attempt for the sink, retrying events are claimed again after retryAfter.
+func (s *OutboxTable) FinishOutbox(ctx context.Context, sink string, event *OutboxEvent, retryAfter time.Duration) error {
+	ctx, done := observe(ctx, "OutboxTable", "FinishOutbox")
+	defer done()
+	_, err := s.DB.ExecContext(ctx, `UPDATE outbox_deliveries SET "status" = $1, "lastError" = $2,
+		"nextAttemptAt" = now() + $3 * interval '1 millisecond', "publishedAt" = CASE WHEN $1::text = 'published' THEN now() END
+		WHERE "outboxId" = $4 AND "sink" = $5`, event.Status, event.LastError, retryAfter.Milliseconds(), event.Id, sink)
+	if err != nil {
+		logger.Err.Println("can't update outbox_deliveries table - ", err)
+	}
+	return err
+}
+
+// CompleteOutbox marks events published to every sink or dead in them as published,
+// such events are no longer looked through by claims and can be pruned.
+func (s *OutboxTable) CompleteOutbox(ctx context.Context, sinks []string) (int64, error) {
+	ctx, done := observe(ctx, "OutboxTable", "CompleteOutbox")
+	defer done()
+	res, err := s.DB.ExecContext(ctx, `UPDATE outbox o SET "publishedAt" = now() WHERE o."publishedAt" IS NULL
+		AND (SELECT count(*) FROM outbox_deliveries d WHERE d."outboxId" = o."id" AND d."sink" = ANY($1::text[])
+			AND d."status" IN ('published', 'dead')) = cardinality($1::text[])`, pq.Array(sinks))
+	if err != nil {
+		logger.Err.Println("can't update outbox table - ", err)
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// PruneOutbox deletes events published earlier than the given age.
+func (s *OutboxTable) PruneOutbox(ctx context.Context, olderThan time.Duration) (int64, error) {
+	ctx, done := observe(ctx, "OutboxTable", "PruneOutbox")
+	defer done()
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM outbox WHERE "publishedAt" < now() - $1 * interval '1 second'`, olderThan.Seconds())
+	if err != nil {
+		logger.Err.Println("can't delete from outbox table - ", err)
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// writeEvent adds the event into the outbox within the transaction of the change.
+func writeEvent(ctx context.Context, tx *sql.Tx, aggregateType string, aggregateId int, event string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		logger.Err.Printf("can't encode %s event - %v\n", event, err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO outbox ("aggregateType", "aggregateId", "event", "data") VALUES ($1, $2, $3, $4)`,
+					aggregateType, aggregateId, event, string(encoded))
+	if err != nil {
+		logger.Err.Println("can't insert into outbox table - ", err)
+	}
+	return err
+}
+
+// inTx runs the change in a transaction, it is rolled back when the change fails.
+func inTx(ctx context.Context, db *sql.DB, change func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Err.Println("can't begin transaction - ", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := change(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Err.Println("can't commit transaction - ", err)
+		return err
+	}
+	return nil
+}
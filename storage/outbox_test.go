@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"os"
+	"testing"
+	"time"
+
+	"songsapi/logger"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+)
+
+func TestMain(m *testing.M) {
+	logger.DoConsoleLog()
+	os.Exit(m.Run())
+}
+
+// testDB connects to TEST_DATABASE_URL with all migrations applied, the outbox is emptied.
+// Tests using it are skipped without the variable.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	m, err := migrate.New("file://../migrations", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if _, err := db.Exec(`TRUNCATE outbox, outbox_deliveries`); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestClaimOutbox(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	outbox := &OutboxTable{DB: db}
+
+	// events 0 and 1 are changes of the same song, event 2 is another song
+	ids := make([]int64, 3)
+	for i, songId := range []int{ 1, 1, 2 } {
+		err := inTx(ctx, db, func(tx *sql.Tx) error {
+			if err := writeEvent(ctx, tx, AggregateSong, songId, EventSongUpdated, map[string]int{ "id": songId }); err != nil {
+				return err
+			}
+			return tx.QueryRowContext(ctx, `SELECT max("id") FROM outbox`).Scan(&ids[i])
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps := []struct {
+		name	string
+		sink	string
+		// dead events of the sink, as if they failed MaxAttempts times
+		dead	[]int
+		want	[]int
+		// results of claimed events, published if missing
+		results	map[int]string
+	}{
+		{ name: "first event of every aggregate", sink: "a", want: []int{ 0, 2 }, results: map[int]string{ 0: OutboxRetrying } },
+		{ name: "backing off event holds back its aggregate", sink: "a", want: []int{} },
+		{ name: "other sink isn't held back", sink: "b", want: []int{ 0, 2 } },
+		{ name: "next event after publishing", sink: "b", want: []int{ 1 } },
+		{ name: "nothing left", sink: "b", want: []int{} },
+		{ name: "dead event releases its aggregate", sink: "a", dead: []int{ 0 }, want: []int{ 1 } },
+	}
+
+	for _, step := range steps {
+		for _, i := range step.dead {
+			if err := outbox.FinishOutbox(ctx, step.sink, &OutboxEvent{ Id: ids[i], Status: OutboxDead }, 0); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		events, err := outbox.ClaimOutbox(ctx, step.sink, 10, time.Minute)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		got := make([]int, 0)
+		for _, event := range events {
+			for i, id := range ids {
+				if event.Id == id {
+					got = append(got, i)
+				}
+			}
+
+			event.Status = OutboxPublished
+			retryAfter := time.Duration(0)
+			if status, ok := step.results[got[len(got) - 1]]; ok {
+				event.Status, retryAfter = status, time.Hour
+			}
+			if err := outbox.FinishOutbox(ctx, step.sink, event, retryAfter); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: claimed events %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	completed, err := outbox.CompleteOutbox(ctx, []string{ "a", "b" })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if completed != 3 {
+		t.Errorf("%d events completed, want 3", completed)
+	}
+}
@@ -1,10 +1,14 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"songsapi/logger"
 	"songsapi/query"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 type Song struct {
@@ -15,28 +19,74 @@ type Song struct {
 	Text        string 	`json:"text,omitempty"`
 	Link        string 	`json:"link,omitempty"`
 	GroupId		int		`json:"groupId,omitempty"`
+	Lang		string	`json:"lang,omitempty"`
+	Status		string	`json:"status,omitempty"`
+	EnrichedAt	*time.Time	`json:"enrichedAt,omitempty"`
+	UpdatedAt	*time.Time	`json:"updatedAt,omitempty"`
 }
 
+const (
+	StatusEnriched = "enriched"
+	StatusPendingEnrichment = "pending_enrichment"
+	StatusEnrichmentFailed = "enrichment_failed"
+)
+
+// SongStorage writes song.* events into the outbox in the transaction of every change.
 type SongStorage struct {
 	DB *sql.DB
 }
 
-func (s *SongStorage) Get(id int) (*Song, error) {
+// DuplicateSongError is returned when a song with the same normalized name
+// already exists in the group.
+type DuplicateSongError struct {
+	ExistingId	int
+}
+
+func (e *DuplicateSongError) Error() string {
+	return fmt.Sprintf("song already exists with id = %d", e.ExistingId)
+}
+
+func (s *SongStorage) Get(ctx context.Context, id int) (*Song, error) {
+	ctx, done := observe(ctx, "SongStorage", "Get")
+	defer done()
 	song := Song{}
-	err := s.DB.QueryRow("SELECT * FROM songs WHERE id = $1", id).Scan(
-		&song.Id, &song.GroupId, &song.Name, &song.ReleaseDate, &song.Text, &song.Link)
+	var releaseDate sql.NullString
+	var enrichedAt sql.NullTime
+	var updatedAt time.Time
+	err := s.DB.QueryRowContext(ctx, `SELECT "id", "groupId", "name", "releaseDate", "text", "link", "lang", "status", "enrichedAt", "updatedAt" 
+						FROM songs WHERE id = $1`, id).Scan(
+		&song.Id, &song.GroupId, &song.Name, &releaseDate, &song.Text, &song.Link, &song.Lang, &song.Status, &enrichedAt, &updatedAt)
 	if err != nil {
 		logger.Err.Println("can't find song with id = ", id)
 		return nil, err
 	}
+	song.ReleaseDate = releaseDate.String
+	song.UpdatedAt = &updatedAt
+	if enrichedAt.Valid {
+		song.EnrichedAt = &enrichedAt.Time
+	}
 	
 	return &song, err
 }
 
-func (s *SongStorage) Create(song *Song) error {
-	_, err := s.DB.Exec(`INSERT INTO songs ("groupId", "name", "releaseDate", "text", "link") VALUES ($1, $2, $3, $4, $5)`, 
-						song.GroupId, song.Name, song.ReleaseDate, song.Text, song.Link)
+func (s *SongStorage) Create(ctx context.Context, song *Song) error {
+	ctx, done := observe(ctx, "SongStorage", "Create")
+	defer done()
+	err := inTx(ctx, s.DB, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(ctx, `INSERT INTO songs ("groupId", "name", "releaseDate", "text", "link", "lang", "status", "enrichedAt") 
+						VALUES ($1, $2, NULLIF($3, '')::date, $4, $5, $6, COALESCE(NULLIF($7, ''), 'enriched'), $8) RETURNING "id", "updatedAt"`, 
+						song.GroupId, song.Name, song.ReleaseDate, song.Text, song.Link, song.Lang, song.Status, song.EnrichedAt).Scan(
+						&song.Id, &song.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return writeEvent(ctx, tx, AggregateSong, song.Id, EventSongCreated, song)
+	})
+
 	if err != nil {
+		if isSongNameViolation(err) {
+			return s.duplicateOf(ctx, song)
+		}
 		logger.Err.Println("can't insert into songs table - ", err)
 		return err
 	}
@@ -44,8 +94,20 @@ func (s *SongStorage) Create(song *Song) error {
 	return nil
 }
 
-func (s *SongStorage) Delete(song *Song) error {
-	_, err := s.DB.Exec(`DELETE FROM songs WHERE id = $1`, song.Id)
+func (s *SongStorage) Delete(ctx context.Context, song *Song) error {
+	ctx, done := observe(ctx, "SongStorage", "Delete")
+	defer done()
+	err := inTx(ctx, s.DB, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, `DELETE FROM songs WHERE id = $1`, song.Id)
+		if err != nil {
+			return err
+		}
+		if affected, _ := res.RowsAffected(); affected == 0 {
+			return nil
+		}
+		return writeEvent(ctx, tx, AggregateSong, song.Id, EventSongDeleted, &DeletedEvent{ Id: song.Id })
+	})
+
 	if err != nil {
 		logger.Err.Println("can't delete from songs table - ", err)
 		return err
@@ -54,18 +116,56 @@ func (s *SongStorage) Delete(song *Song) error {
 	return nil
 }
 
-func (s *SongStorage) Update(song *Song) error {
-	_, err := s.DB.Exec(`UPDATE songs SET "groupId" = $1, "name" = $2, "releaseDate" = $3, "text" = $4, "link" = $5
-						WHERE songs.id = $6`, song.GroupId, song.Name, song.ReleaseDate, song.Text, song.Link, song.Id)
-	
+func (s *SongStorage) Update(ctx context.Context, song *Song) error {
+	ctx, done := observe(ctx, "SongStorage", "Update")
+	defer done()
+	err := inTx(ctx, s.DB, func(tx *sql.Tx) error {
+		return updateSong(ctx, tx, song)
+	})
 	if err != nil {
+		if isSongNameViolation(err) {
+			return s.duplicateOf(ctx, song)
+		}
 		logger.Err.Println("can't update songs table - ", err)
 		return err
 	}
 	return nil			
 }
 
-func (s *SongStorage) Find(q query.Query) ([]*Song, error) {
+// updateSong saves the song with its song.updated event, it is shared by Update and RefreshSong.
+func updateSong(ctx context.Context, tx *sql.Tx, song *Song) error {
+	res, err := tx.ExecContext(ctx, `UPDATE songs SET "groupId" = $1, "name" = $2, "releaseDate" = NULLIF($3, '')::date, "text" = $4, "link" = $5, "lang" = $6,
+						"status" = COALESCE(NULLIF($7, ''), "status"), "enrichedAt" = COALESCE($8::timestamp, "enrichedAt") WHERE songs.id = $9`, 
+						song.GroupId, song.Name, song.ReleaseDate, song.Text, song.Link, song.Lang, song.Status, song.EnrichedAt, song.Id)
+	if err != nil {
+		return err
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return nil
+	}
+	return writeEvent(ctx, tx, AggregateSong, song.Id, EventSongUpdated, song)
+}
+
+func (s *SongStorage) duplicateOf(ctx context.Context, song *Song) error {
+	var existingId int
+	err := s.DB.QueryRowContext(ctx, `SELECT id FROM songs WHERE "groupId" = $1 AND normalize_title("name") = normalize_title($2) AND id <> $3`,
+						song.GroupId, song.Name, song.Id).Scan(&existingId)
+	if err != nil {
+		logger.Err.Println("can't find duplicated song - ", err)
+		return err
+	}
+
+	return &DuplicateSongError{ ExistingId: existingId }
+}
+
+func isSongNameViolation(err error) bool {
+	pgErr, ok := err.(*pq.Error)
+	return ok && pgErr.Code == "23505" && pgErr.Constraint == "unique_song_name"
+}
+
+func (s *SongStorage) Find(ctx context.Context, q query.Query) ([]*Song, error) {
+	ctx, done := observe(ctx, "SongStorage", "Find")
+	defer done()
 	songQuery, ok := q.(*query.SongQuery)
 	if !ok {
 		return nil, fmt.Errorf("can't convert search query into songQuery")
@@ -78,7 +178,7 @@ func (s *SongStorage) Find(q query.Query) ([]*Song, error) {
 
 	// fmt.Println("Generated SQL:", query)
 
-	rows, err := s.DB.Query(query)
+	rows, err := s.DB.QueryContext(ctx, query)
 
 	if err != nil {
 		logger.Err.Println("error during songs search - ", err)
@@ -91,10 +191,17 @@ func (s *SongStorage) Find(q query.Query) ([]*Song, error) {
 	for rows.Next() {
 		noRowsFound = false
 		song := Song{}
-		if err := rows.Scan(&song.Id, &song.Name, &song.ReleaseDate, &song.Text, &song.Link, &song.Group); err != nil {
+		var releaseDate sql.NullString
+		var enrichedAt sql.NullTime
+		if err := rows.Scan(&song.Id, &song.Name, &releaseDate, &song.Text, &song.Link, &song.Group, &song.Lang, &song.Status, 
+							&enrichedAt, &song.UpdatedAt, &song.GroupId); err != nil {
 			logger.Err.Println("can't scan songs table row:", err)
             continue
 		}
+		song.ReleaseDate = releaseDate.String
+		if enrichedAt.Valid {
+			song.EnrichedAt = &enrichedAt.Time
+		}
 		songs = append(songs, &song)
 	}
 
@@ -104,3 +211,48 @@ func (s *SongStorage) Find(q query.Query) ([]*Song, error) {
 
 	return songs, nil
 }
+
+// SongsOfGroups pages songs of every group separately, like Find with page and limit does for one group:
+// zero page returns all songs, zero limit means 10.
+func (s *SongStorage) SongsOfGroups(ctx context.Context, groupIds []int, page, limit int) (map[int][]*Song, error) {
+	ctx, done := observe(ctx, "SongStorage", "SongsOfGroups")
+	defer done()
+	if page == 0 {
+		limit = 0
+	} else if limit == 0 {
+		limit = 10
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `SELECT s."id", s."name", s."releaseDate", s."text", s."link", g."name", s."lang", s."status",
+			s."enrichedAt", s."updatedAt", s."groupId"
+		FROM (SELECT *, row_number() OVER (PARTITION BY "groupId" ORDER BY "id") AS "position" 
+			FROM songs WHERE "groupId" = ANY($1)) s
+		JOIN "groups" g ON s."groupId" = g."id"
+		WHERE $2::int = 0 OR (s."position" > $3::int AND s."position" <= $3::int + $2::int)
+		ORDER BY s."groupId", s."id"`, pq.Array(groupIds), limit, limit * (page - 1))
+	if err != nil {
+		logger.Err.Println("error during songs of groups search - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	byGroup := make(map[int][]*Song, len(groupIds))
+	for rows.Next() {
+		song := Song{}
+		var releaseDate sql.NullString
+		var enrichedAt sql.NullTime
+		if err := rows.Scan(&song.Id, &song.Name, &releaseDate, &song.Text, &song.Link, &song.Group, &song.Lang, &song.Status, 
+							&enrichedAt, &song.UpdatedAt, &song.GroupId); err != nil {
+			logger.Err.Println("can't scan songs table row:", err)
+			continue
+		}
+		song.ReleaseDate = releaseDate.String
+		if enrichedAt.Valid {
+			song.EnrichedAt = &enrichedAt.Time
+		}
+		byGroup[song.GroupId] = append(byGroup[song.GroupId], &song)
+	}
+
+	return byGroup, rows.Err()
+}
@@ -1,13 +1,24 @@
 package storage
 
 import (
+	"context"
 	"songsapi/query"
 )
 
 type Storage[T any] interface {
-	Get(id int) (*T, error)
-	Create(model *T) error
-	Delete(model *T) error
-	Update(model *T) error
-	Find(q query.Query) ([]*T, error)
+	Get(ctx context.Context, id int) (*T, error)
+	Create(ctx context.Context, model *T) error
+	Delete(ctx context.Context, model *T) error
+	Update(ctx context.Context, model *T) error
+	Find(ctx context.Context, q query.Query) ([]*T, error)
+}
+
+// BatchGetter loads many records in one query, missing ids are skipped.
+type BatchGetter[T any] interface {
+	GetMany(ctx context.Context, ids []int) ([]*T, error)
+}
+
+// GroupSongsGetter loads songs of many groups in one query, page and limit apply to every group.
+type GroupSongsGetter interface {
+	SongsOfGroups(ctx context.Context, groupIds []int, page, limit int) (map[int][]*Song, error)
 }
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"songsapi/logger"
+	"time"
+)
+
+type SyncedLyrics struct {
+	SongId		int
+	LRC			string
+	UpdatedAt	time.Time
+}
+
+type SyncedLyricsStorage interface {
+	GetLyrics(ctx context.Context, songId int) (*SyncedLyrics, error)
+	SaveLyrics(ctx context.Context, lyrics *SyncedLyrics) error
+	DeleteLyrics(ctx context.Context, songId int) error
+}
+
+type SyncedLyricsTable struct {
+	DB *sql.DB
+}
+
+func (s *SyncedLyricsTable) GetLyrics(ctx context.Context, songId int) (*SyncedLyrics, error) {
+	ctx, done := observe(ctx, "SyncedLyricsTable", "GetLyrics")
+	defer done()
+	lyrics := SyncedLyrics{}
+	err := s.DB.QueryRowContext(ctx, `SELECT "songId", "lrc", "updatedAt" FROM song_synced_lyrics WHERE "songId" = $1`, songId).Scan(
+		&lyrics.SongId, &lyrics.LRC, &lyrics.UpdatedAt)
+	if err != nil {
+		logger.Err.Println("can't find synced lyrics for song with id = ", songId)
+		return nil, err
+	}
+
+	return &lyrics, nil
+}
+
+func (s *SyncedLyricsTable) SaveLyrics(ctx context.Context, lyrics *SyncedLyrics) error {
+	ctx, done := observe(ctx, "SyncedLyricsTable", "SaveLyrics")
+	defer done()
+	err := s.DB.QueryRowContext(ctx, `INSERT INTO song_synced_lyrics ("songId", "lrc") VALUES ($1, $2)
+						ON CONFLICT ("songId") DO UPDATE SET "lrc" = EXCLUDED."lrc", "updatedAt" = now()
+						RETURNING "updatedAt"`, lyrics.SongId, lyrics.LRC).Scan(&lyrics.UpdatedAt)
+	if err != nil {
+		logger.Err.Println("can't save synced lyrics - ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *SyncedLyricsTable) DeleteLyrics(ctx context.Context, songId int) error {
+	ctx, done := observe(ctx, "SyncedLyricsTable", "DeleteLyrics")
+	defer done()
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM song_synced_lyrics WHERE "songId" = $1`, songId)
+	if err != nil {
+		logger.Err.Println("can't delete from song_synced_lyrics table - ", err)
+		return err
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
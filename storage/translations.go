@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"songsapi/logger"
+)
+
+// Translation is a lyrics variant of the song in another language.
+type Translation struct {
+	Id			int		`json:"id"`
+	SongId		int		`json:"songId"`
+	Lang		string	`json:"lang"`
+	Text		string	`json:"text"`
+	Translator	string	`json:"translator,omitempty"`
+}
+
+type TranslationStorage interface {
+	Translations(ctx context.Context, songId int) ([]*Translation, error)
+	GetTranslation(ctx context.Context, songId int, lang string) (*Translation, error)
+	SaveTranslation(ctx context.Context, translation *Translation) error
+	DeleteTranslation(ctx context.Context, songId int, lang string) error
+}
+
+type TranslationsTable struct {
+	DB *sql.DB
+}
+
+func (s *TranslationsTable) Translations(ctx context.Context, songId int) ([]*Translation, error) {
+	ctx, done := observe(ctx, "TranslationsTable", "Translations")
+	defer done()
+	rows, err := s.DB.QueryContext(ctx, `SELECT "id", "songId", "lang", "text", "translator" FROM song_translations
+							WHERE "songId" = $1 ORDER BY "lang"`, songId)
+	if err != nil {
+		logger.Err.Println("song translations search failed - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	translations := make([]*Translation, 0)
+	for rows.Next() {
+		translation := Translation{}
+		if err := rows.Scan(&translation.Id, &translation.SongId, &translation.Lang, &translation.Text, &translation.Translator); err != nil {
+			logger.Err.Println("can't scan song_translations row:", err)
+			continue
+		}
+		translations = append(translations, &translation)
+	}
+
+	return translations, nil
+}
+
+func (s *TranslationsTable) GetTranslation(ctx context.Context, songId int, lang string) (*Translation, error) {
+	ctx, done := observe(ctx, "TranslationsTable", "GetTranslation")
+	defer done()
+	translation := Translation{}
+	err := s.DB.QueryRowContext(ctx, `SELECT "id", "songId", "lang", "text", "translator" FROM song_translations
+						WHERE "songId" = $1 AND "lang" = $2`, songId, lang).Scan(
+		&translation.Id, &translation.SongId, &translation.Lang, &translation.Text, &translation.Translator)
+	if err != nil {
+		logger.Err.Printf("can't find %s translation for song with id = %d\n", lang, songId)
+		return nil, err
+	}
+
+	return &translation, nil
+}
+
+func (s *TranslationsTable) SaveTranslation(ctx context.Context, translation *Translation) error {
+	ctx, done := observe(ctx, "TranslationsTable", "SaveTranslation")
+	defer done()
+	err := s.DB.QueryRowContext(ctx, `INSERT INTO song_translations ("songId", "lang", "text", "translator") VALUES ($1, $2, $3, $4)
+						ON CONFLICT ("songId", "lang") DO UPDATE SET "text" = EXCLUDED."text", "translator" = EXCLUDED."translator"
+						RETURNING "id"`, translation.SongId, translation.Lang, translation.Text, translation.Translator).Scan(&translation.Id)
+	if err != nil {
+		logger.Err.Println("can't save song translation - ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *TranslationsTable) DeleteTranslation(ctx context.Context, songId int, lang string) error {
+	ctx, done := observe(ctx, "TranslationsTable", "DeleteTranslation")
+	defer done()
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM song_translations WHERE "songId" = $1 AND "lang" = $2`, songId, lang)
+	if err != nil {
+		logger.Err.Println("can't delete from song_translations table - ", err)
+		return err
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"songsapi/logger"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	DeliveryPending = "pending"
+	DeliverySending = "sending"
+	DeliveryRetrying = "retrying"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed = "failed"
+)
+
+// Webhook is a subscription of the URL to song and group events. Events are exact
+// names like song.created, wildcards of the kind like group.* or * for everything.
+type Webhook struct {
+	Id			int			`json:"id"`
+	URL			string		`json:"url"`
+	Events		[]string	`json:"events"`
+	Secret		string		`json:"secret,omitempty"`
+	Active		bool		`json:"active"`
+	CreatedAt	time.Time	`json:"createdAt"`
+}
+
+// WebhookDelivery is a single event sent to the webhook, it is kept as the delivery log.
+type WebhookDelivery struct {
+	Id				int				`json:"id"`
+	WebhookId		int				`json:"webhookId"`
+	Event			string			`json:"event"`
+	Payload			json.RawMessage	`json:"payload" swaggertype:"object"`
+	Status			string			`json:"status"`
+	Attempts		int				`json:"attempts"`
+	ResponseStatus	int				`json:"responseStatus,omitempty"`
+	LastError		string			`json:"lastError,omitempty"`
+	RunAt			time.Time		`json:"runAt"`
+	CreatedAt		time.Time		`json:"createdAt"`
+	DeliveredAt		*time.Time		`json:"deliveredAt,omitempty"`
+}
+
+type WebhookStorage interface {
+	CreateWebhook(ctx context.Context, hook *Webhook) error
+	GetWebhook(ctx context.Context, id int) (*Webhook, error)
+	Webhooks(ctx context.Context) ([]*Webhook, error)
+	UpdateWebhook(ctx context.Context, hook *Webhook) error
+	DeleteWebhook(ctx context.Context, id int) error
+
+	EnqueueDeliveries(ctx context.Context, outboxId int64, event string, payload []byte) (int64, error)
+	ClaimDelivery(ctx context.Context, lease time.Duration) (*WebhookDelivery, error)
+	FinishDelivery(ctx context.Context, delivery *WebhookDelivery, retryAfter time.Duration) error
+	GetDelivery(ctx context.Context, id int) (*WebhookDelivery, error)
+	Deliveries(ctx context.Context, webhookId int, status string, limit int) ([]*WebhookDelivery, error)
+	Redeliver(ctx context.Context, id int) (*WebhookDelivery, error)
+}
+
+type WebhooksTable struct {
+	DB *sql.DB
+}
+
+const webhookColumns = `"id", "url", "events", "secret", "active", "createdAt"`
+
+const deliveryColumns = `"id", "webhookId", "event", "payload", "status", "attempts", "responseStatus", "lastError",
+	"runAt", "createdAt", "deliveredAt"`
+
+func scanWebhook(scan func(dest ...any) error) (*Webhook, error) {
+	hook := Webhook{}
+	err := scan(&hook.Id, &hook.URL, pq.Array(&hook.Events), &hook.Secret, &hook.Active, &hook.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+func scanDelivery(scan func(dest ...any) error) (*WebhookDelivery, error) {
+	delivery := WebhookDelivery{}
+	var payload string
+	var deliveredAt sql.NullTime
+	err := scan(&delivery.Id, &delivery.WebhookId, &delivery.Event, &payload, &delivery.Status, &delivery.Attempts,
+				&delivery.ResponseStatus, &delivery.LastError, &delivery.RunAt, &delivery.CreatedAt, &deliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = json.RawMessage(payload)
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return &delivery, nil
+}
+
+func (s *WebhooksTable) CreateWebhook(ctx context.Context, hook *Webhook) error {
+	ctx, done := observe(ctx, "WebhooksTable", "CreateWebhook")
+	defer done()
+	err := s.DB.QueryRowContext(ctx, `INSERT INTO webhooks ("url", "events", "secret", "active") VALUES ($1, $2, $3, $4)
+						RETURNING "id", "createdAt"`, hook.URL, pq.Array(hook.Events), hook.Secret, hook.Active).Scan(
+						&hook.Id, &hook.CreatedAt)
+	if err != nil {
+		logger.Err.Println("can't insert into webhooks table - ", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *WebhooksTable) GetWebhook(ctx context.Context, id int) (*Webhook, error) {
+	ctx, done := observe(ctx, "WebhooksTable", "GetWebhook")
+	defer done()
+	hook, err := scanWebhook(s.DB.QueryRowContext(ctx, `SELECT ` + webhookColumns + ` FROM webhooks WHERE "id" = $1`, id).Scan)
+	if err != nil {
+		logger.Err.Println("can't find webhook with id = ", id)
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+func (s *WebhooksTable) Webhooks(ctx context.Context) ([]*Webhook, error) {
+	ctx, done := observe(ctx, "WebhooksTable", "Webhooks")
+	defer done()
+	rows, err := s.DB.QueryContext(ctx, `SELECT ` + webhookColumns + ` FROM webhooks ORDER BY "id"`)
+	if err != nil {
+		logger.Err.Println("webhooks search failed - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	hooks := make([]*Webhook, 0)
+	for rows.Next() {
+		hook, err := scanWebhook(rows.Scan)
+		if err != nil {
+			logger.Err.Println("can't scan webhooks row:", err)
+			continue
+		}
+		hooks = append(hooks, hook)
+	}
+
+	return hooks, rows.Err()
+}
+
+func (s *WebhooksTable) UpdateWebhook(ctx context.Context, hook *Webhook) error {
+	ctx, done := observe(ctx, "WebhooksTable", "UpdateWebhook")
+	defer done()
+	res, err := s.DB.ExecContext(ctx, `UPDATE webhooks SET "url" = $1, "events" = $2, "secret" = $3, "active" = $4 WHERE "id" = $5`,
+						hook.URL, pq.Array(hook.Events), hook.Secret, hook.Active, hook.Id)
+	if err != nil {
+		logger.Err.Println("can't update webhooks table - ", err)
+		return err
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeleteWebhook removes the webhook together with its delivery log.
+func (s *WebhooksTable) DeleteWebhook(ctx context.Context, id int) error {
+	ctx, done := observe(ctx, "WebhooksTable", "DeleteWebhook")
+	defer done()
+	res, err := s.DB.ExecContext(ctx, `DELETE FROM webhooks WHERE "id" = $1`, id)
+	if err != nil {
+		logger.Err.Println("can't delete from webhooks table - ", err)
+		return err
+	}
+
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// EnqueueDeliveries creates a pending delivery of the payload for every active webhook
+// subscribed to the event and returns the number of created deliveries. Events already
+// queued for the webhook with the same outbox id are skipped.
+func (s *WebhooksTable) EnqueueDeliveries(ctx context.Context, outboxId int64, event string, payload []byte) (int64, error) {
+	ctx, done := observe(ctx, "WebhooksTable", "EnqueueDeliveries")
+	defer done()
+	res, err := s.DB.ExecContext(ctx, `INSERT INTO webhook_deliveries ("webhookId", "outboxId", "event", "payload")
+		SELECT "id", $1::bigint, $2::text, $3::text FROM webhooks WHERE "active"
+			AND ($2::text = ANY("events") OR split_part($2::text, '.', 1) || '.*' = ANY("events") OR '*' = ANY("events"))
+		ON CONFLICT DO NOTHING`,
+		outboxId, event, string(payload))
+	if err != nil {
+		logger.Err.Println("can't insert into webhook_deliveries table - ", err)
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// ClaimDelivery marks the oldest runnable delivery as sending for the lease duration,
+// the same way as ClaimJob does. sql.ErrNoRows means nothing to send.
+func (s *WebhooksTable) ClaimDelivery(ctx context.Context, lease time.Duration) (*WebhookDelivery, error) {
+	ctx, done := observe(ctx, "WebhooksTable", "ClaimDelivery")
+	defer done()
+	delivery, err := scanDelivery(s.DB.QueryRowContext(ctx, `UPDATE webhook_deliveries SET "status" = 'sending', "attempts" = "attempts" + 1,
+		"runAt" = now() + $1 * interval '1 millisecond'
+		WHERE "id" = (SELECT "id" FROM webhook_deliveries WHERE "status" IN ('pending', 'retrying', 'sending') AND "runAt" <= now()
+			ORDER BY "runAt" FOR UPDATE SKIP LOCKED LIMIT 1)
+		RETURNING ` + deliveryColumns, lease.Milliseconds()).Scan)
+	if err != nil && err != sql.ErrNoRows {
+		logger.Err.Println("can't claim webhook delivery - ", err)
+	}
+
+	return delivery, err
+}
+
+// FinishDelivery saves the result of the attempt, retrying deliveries are sent again after retryAfter.
+func (s *WebhooksTable) FinishDelivery(ctx context.Context, delivery *WebhookDelivery, retryAfter time.Duration) error {
+	ctx, done := observe(ctx, "WebhooksTable", "FinishDelivery")
+	defer done()
+	var deliveredAt sql.NullTime
+	err := s.DB.QueryRowContext(ctx, `UPDATE webhook_deliveries SET "status" = $1, "responseStatus" = $2, "lastError" = $3,
+		"runAt" = now() + $4 * interval '1 millisecond', "deliveredAt" = CASE WHEN $1::text = 'delivered' THEN now() END
+		WHERE "id" = $5 RETURNING "runAt", "deliveredAt"`,
+		delivery.Status, delivery.ResponseStatus, delivery.LastError, retryAfter.Milliseconds(), delivery.Id).Scan(
+		&delivery.RunAt, &deliveredAt)
+	if err != nil {
+		logger.Err.Println("can't update webhook_deliveries table - ", err)
+		return err
+	}
+
+	if deliveredAt.Valid {
+		delivery.DeliveredAt = &deliveredAt.Time
+	}
+	return nil
+}
+
+func (s *WebhooksTable) GetDelivery(ctx context.Context, id int) (*WebhookDelivery, error) {
+	ctx, done := observe(ctx, "WebhooksTable", "GetDelivery")
+	defer done()
+	delivery, err := scanDelivery(s.DB.QueryRowContext(ctx, `SELECT ` + deliveryColumns + ` FROM webhook_deliveries WHERE "id" = $1`, id).Scan)
+	if err != nil {
+		logger.Err.Println("can't find webhook delivery with id = ", id)
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// Deliveries returns the latest deliveries of the webhook, empty status means any status.
+func (s *WebhooksTable) Deliveries(ctx context.Context, webhookId int, status string, limit int) ([]*WebhookDelivery, error) {
+	ctx, done := observe(ctx, "WebhooksTable", "Deliveries")
+	defer done()
+	rows, err := s.DB.QueryContext(ctx, `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+							WHERE "webhookId" = $1 AND ($2 = '' OR "status" = $2) ORDER BY "id" DESC LIMIT $3`,
+							webhookId, status, limit)
+	if err != nil {
+		logger.Err.Println("webhook deliveries search failed - ", err)
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	deliveries := make([]*WebhookDelivery, 0)
+	for rows.Next() {
+		delivery, err := scanDelivery(rows.Scan)
+		if err != nil {
+			logger.Err.Println("can't scan webhook_deliveries row:", err)
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// Redeliver queues a new delivery with the payload of the given one,
+// the original delivery stays in the log as it is.
+func (s *WebhooksTable) Redeliver(ctx context.Context, id int) (*WebhookDelivery, error) {
+	ctx, done := observe(ctx, "WebhooksTable", "Redeliver")
+	defer done()
+	delivery, err := scanDelivery(s.DB.QueryRowContext(ctx, `INSERT INTO webhook_deliveries ("webhookId", "event", "payload")
+		SELECT "webhookId", "event", "payload" FROM webhook_deliveries WHERE "id" = $1
+		RETURNING ` + deliveryColumns, id).Scan)
+	if err != nil {
+		logger.Err.Println("can't redeliver webhook delivery with id = ", id)
+		return nil, err
+	}
+
+	return delivery, nil
+}
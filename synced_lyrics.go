@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"songsapi/logger"
+	"songsapi/lyrics"
+	"songsapi/storage"
+
+	"github.com/gorilla/mux"
+)
+
+type SyncedLyricsHandler struct {
+	SongsTable 	storage.Storage[storage.Song]
+	LyricsTable	storage.SyncedLyricsStorage
+}
+
+type SyncedLineResponse struct {
+	Index		int
+	Time		int64
+	Line		string
+	Next		*lyrics.TimedLine
+}
+
+// maximum size of uploaded .lrc file
+const maxLRCSize = 1 << 20
+
+func (h *SyncedLyricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	songId, _ := strconv.Atoi(mux.Vars(r)["id"])
+	if _, err := h.SongsTable.Get(r.Context(), songId); err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	switch {
+
+	case r.Method == http.MethodPut:
+		h.upload(w, r, songId)
+
+	case r.Method == http.MethodDelete:
+		h.delete(w, r, songId)
+
+	case strings.HasSuffix(r.URL.Path, ".lrc"):
+		h.download(w, r, songId)
+
+	case strings.HasSuffix(r.URL.Path, "/at"):
+		h.lineAt(w, r, songId)
+
+	default:
+		h.timedLines(w, r, songId)
+	}
+}
+
+// @Tags synced lyrics
+// @Summary Uploads time-coded lyrics in LRC format
+// @Router /songs/{id}/lyrics.lrc [put]
+// @Accept plain
+// @Param id path int true "Song ID"
+// @Param request body string true "LRC file content"
+// @Success 204
+// @Failure 400
+// @Failure 404
+// @Failure 500
+func (h *SyncedLyricsHandler) upload(w http.ResponseWriter, r *http.Request, songId int) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxLRCSize + 1))
+	if err != nil {
+		logger.Err.Println("can't read lrc file - ", err)
+		http.Error(w, "Can't read request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(body) > maxLRCSize {
+		http.Error(w, "LRC file is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	text := lyrics.Normalize(string(body))
+	if _, err := lyrics.ParseLRC(text); err != nil {
+		logger.Err.Println("lrc validation failed - ", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.LyricsTable.SaveLyrics(r.Context(), &storage.SyncedLyrics{ SongId: songId, LRC: text }); err != nil {
+		http.Error(w, fmt.Sprintf("Can't save lyrics for song with id = %d, Error: %v", songId, err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Tags synced lyrics
+// @Summary Deletes time-coded lyrics
+// @Router /songs/{id}/lyrics.lrc [delete]
+// @Param id path int true "Song ID"
+// @Success 204
+// @Failure 404
+// @Failure 500
+func (h *SyncedLyricsHandler) delete(w http.ResponseWriter, r *http.Request, songId int) {
+	if err := h.LyricsTable.DeleteLyrics(r.Context(), songId); err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Tags synced lyrics
+// @Summary Downloads time-coded lyrics in LRC format
+// @Router /songs/{id}/lyrics.lrc [get]
+// @Produce plain
+// @Param id path int true "Song ID"
+// @Success 200 {string} string "LRC file content"
+// @Failure 404
+// @Failure 500
+func (h *SyncedLyricsHandler) download(w http.ResponseWriter, r *http.Request, songId int) {
+	synced, err := h.LyricsTable.GetLyrics(r.Context(), songId)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%d.lrc"`, songId))
+	w.Write([]byte(synced.LRC))
+}
+
+// @Tags synced lyrics
+// @Summary Returns time-coded lyrics as {time, line} pairs
+// @Description Time is given in milliseconds from the start of the song
+// @Router /songs/{id}/lyrics [get]
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} lyrics.LRC
+// @Failure 404
+// @Failure 500
+func (h *SyncedLyricsHandler) timedLines(w http.ResponseWriter, r *http.Request, songId int) {
+	lrc, ok := h.parsedLyrics(w, r, songId)
+	if !ok {
+		return
+	}
+
+	Render(w, r, lrc)
+}
+
+// @Tags synced lyrics
+// @Summary Returns the line active at the given playback offset
+// @Router /songs/{id}/lyrics/at [get]
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param offset query int true "Playback offset in milliseconds"
+// @Success 200 {object} SyncedLineResponse
+// @Failure 400
+// @Failure 404
+// @Failure 500
+func (h *SyncedLyricsHandler) lineAt(w http.ResponseWriter, r *http.Request, songId int) {
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		logger.Err.Println("bad request query")
+		http.Error(w, "offset must be a non-negative number of milliseconds", http.StatusBadRequest)
+		return
+	}
+
+	lrc, ok := h.parsedLyrics(w, r, songId)
+	if !ok {
+		return
+	}
+
+	index := lrc.LineAt(offset)
+	if index < 0 {
+		http.Error(w, "No line is active at this offset", http.StatusNotFound)
+		return
+	}
+
+	response := &SyncedLineResponse{
+		Index: index,
+		Time: lrc.Lines[index].Time,
+		Line: lrc.Lines[index].Line,
+	}
+	if index + 1 < len(lrc.Lines) {
+		response.Next = &lrc.Lines[index + 1]
+	}
+
+	Render(w, r, response)
+}
+
+func (h *SyncedLyricsHandler) parsedLyrics(w http.ResponseWriter, r *http.Request, songId int) (*lyrics.LRC, bool) {
+	synced, err := h.LyricsTable.GetLyrics(r.Context(), songId)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return nil, false
+	}
+
+	lrc, err := lyrics.ParseLRC(synced.LRC)
+	if err != nil {
+		logger.Err.Println("stored lrc is invalid - ", err)
+		http.Error(w, "Stored lyrics are invalid", http.StatusInternalServerError)
+		return nil, false
+	}
+
+	return lrc, true
+}
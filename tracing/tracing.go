@@ -0,0 +1,94 @@
+// Package tracing configures OpenTelemetry: the tracer provider with OTLP or file exporter
+// and W3C trace context propagation of incoming and outgoing requests.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"songsapi/config"
+	"songsapi/logger"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+)
+
+type Config struct {
+	Exporter		string
+	File			string
+	ServiceName		string
+	SampleRatio		float64
+}
+
+// ConfigFromEnv reads TRACING_* variables, OTLP endpoint and headers are read by the exporter
+// itself from the standard OTEL_EXPORTER_OTLP_* variables.
+func ConfigFromEnv() Config {
+	return Config{
+		Exporter: config.String("TRACING_EXPORTER", ExporterNone),
+		File: config.String("TRACING_FILE", "traces.jsonl"),
+		ServiceName: config.String("OTEL_SERVICE_NAME", "songsapi"),
+		SampleRatio: config.Float("TRACING_SAMPLE_RATIO", 1),
+	}
+}
+
+// Setup installs the global tracer provider and W3C traceparent propagator. The propagator
+// is installed even without exporter, so traceparent of the caller still reaches the info API.
+// The returned func flushes spans left in the exporter queue.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	case ExporterFile:
+		exporter, err = fileExporter(cfg.File)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't create %s trace exporter - %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		logger.Warn.Println("tracing - ", err)
+	}))
+
+	logger.Debug.Printf("exporting traces of %s to %s\n", cfg.ServiceName, cfg.Exporter)
+	return provider.Shutdown, nil
+}
+
+// fileExporter appends every span as a JSON line, it is meant for offline use without collector.
+func fileExporter(path string) (sdktrace.SpanExporter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return stdouttrace.New(stdouttrace.WithWriter(file))
+}
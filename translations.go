@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"songsapi/logger"
+	"songsapi/lyrics"
+	"songsapi/storage"
+
+	"github.com/gorilla/mux"
+)
+
+type TranslationsHandler struct {
+	SongsTable 		storage.Storage[storage.Song]
+	Translations	storage.TranslationStorage
+	// translations touch updatedAt of the song, so its cached copy has old Last-Modified
+	SongsCache		storage.Purger
+}
+
+type TextAlignHandler struct {
+	SongsTable 		storage.Storage[storage.Song]
+	Translations	storage.TranslationStorage
+}
+
+type TranslationsResponse struct {
+	OriginalLang	string
+	Translations	[]*storage.Translation
+}
+
+type TranslationRequest struct {
+	Text		string	`json:"text"`
+	Translator	string	`json:"translator"`
+}
+
+// AlignedCouplet is a pair of couplets with the same index in two lyrics variants.
+type AlignedCouplet struct {
+	Couplet		int
+	Left		string
+	Right		string
+}
+
+type TextAlignResponse struct {
+	Left		string
+	Right		string
+	Couplets	[]AlignedCouplet
+	Page 		int
+	Limit 		int
+}
+
+var langRegex = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// SongText returns lyrics of the song in the requested language. Original text is
+// returned when lang is empty, equal to the song language or there is no such translation.
+func SongText(ctx context.Context, song *storage.Song, translations storage.TranslationStorage, lang string) (string, string, error) {
+	lang = strings.ToLower(lang)
+	if lang == "" || lang == song.Lang {
+		return song.Text, song.Lang, nil
+	}
+
+	translation, err := translations.GetTranslation(ctx, song.Id, lang)
+	if err == sql.ErrNoRows {
+		return song.Text, song.Lang, nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	return translation.Text, translation.Lang, nil
+}
+
+// exactSongText is SongText without the fallback to the original, a missing translation is sql.ErrNoRows.
+func exactSongText(ctx context.Context, song *storage.Song, translations storage.TranslationStorage, lang string) (string, string, error) {
+	text, foundLang, err := SongText(ctx, song, translations, lang)
+	if err == nil && lang != "" && foundLang != strings.ToLower(lang) {
+		logger.Err.Printf("song %d has no %s translation\n", song.Id, lang)
+		return "", "", sql.ErrNoRows
+	}
+	return text, foundLang, err
+}
+
+func (h *TranslationsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	songId, _ := strconv.Atoi(params["id"])
+	song, err := h.SongsTable.Get(r.Context(), songId)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	lang, ok := params["lang"]
+	if !ok {
+		h.list(w, r, song)
+		return
+	}
+
+	lang = strings.ToLower(lang)
+	if !langRegex.MatchString(lang) {
+		http.Error(w, "lang must be a language code like en or pt-br", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+
+	case http.MethodGet:
+		h.get(w, r, song, lang)
+
+	case http.MethodPut:
+		h.save(w, r, song, lang)
+
+	case http.MethodDelete:
+		h.delete(w, r, song, lang)
+	}
+}
+
+// @Tags translations
+// @Summary Lists lyrics translations of the song
+// @Router /songs/{id}/translations [get]
+// @Produce json
+// @Param id path int true "Song ID"
+// @Success 200 {object} TranslationsResponse
+// @Failure 404
+// @Failure 500
+func (h *TranslationsHandler) list(w http.ResponseWriter, r *http.Request, song *storage.Song) {
+	translations, err := h.Translations.Translations(r.Context(), song.Id)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	Render(w, r, &TranslationsResponse{ OriginalLang: song.Lang, Translations: translations })
+}
+
+// @Tags translations
+// @Summary Returns lyrics translation of the song
+// @Router /songs/{id}/translations/{lang} [get]
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param lang path string true "Language code"
+// @Success 200 {object} storage.Translation
+// @Failure 400
+// @Failure 404
+// @Failure 500
+func (h *TranslationsHandler) get(w http.ResponseWriter, r *http.Request, song *storage.Song, lang string) {
+	translation, err := h.Translations.GetTranslation(r.Context(), song.Id, lang)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	Render(w, r, translation)
+}
+
+// @Tags translations
+// @Summary Adds or replaces lyrics translation of the song
+// @Router /songs/{id}/translations/{lang} [put]
+// @Param id path int true "Song ID"
+// @Param lang path string true "Language code"
+// @Param request body TranslationRequest true "Translated lyrics"
+// @Success 200 {object} storage.Translation
+// @Failure 400
+// @Failure 404
+// @Failure 500
+func (h *TranslationsHandler) save(w http.ResponseWriter, r *http.Request, song *storage.Song, lang string) {
+	var request TranslationRequest
+	defer r.Body.Close()
+	if err := PasreJSON(r.Body, &request); err != nil {
+		http.Error(w, "Can't parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if strings.TrimSpace(request.Text) == "" {
+		http.Error(w, "text is required", http.StatusBadRequest)
+		return
+	}
+
+	if lang == song.Lang {
+		http.Error(w, "Translation language matches the original song language", http.StatusBadRequest)
+		return
+	}
+
+	translation := &storage.Translation{
+		SongId: song.Id,
+		Lang: lang,
+		Text: request.Text,
+		Translator: request.Translator,
+	}
+
+	if err := h.Translations.SaveTranslation(r.Context(), translation); err != nil {
+		http.Error(w, fmt.Sprintf("Can't save translation for song with id = %d, Error: %v", song.Id, err), http.StatusInternalServerError)
+		return
+	}
+
+	h.SongsCache.Purge()
+	Render(w, r, translation)
+}
+
+// @Tags translations
+// @Summary Deletes lyrics translation of the song
+// @Router /songs/{id}/translations/{lang} [delete]
+// @Param id path int true "Song ID"
+// @Param lang path string true "Language code"
+// @Success 204
+// @Failure 400
+// @Failure 404
+// @Failure 500
+func (h *TranslationsHandler) delete(w http.ResponseWriter, r *http.Request, song *storage.Song, lang string) {
+	if err := h.Translations.DeleteTranslation(r.Context(), song.Id, lang); err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	h.SongsCache.Purge()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Tags translations
+// @Summary Returns couplets of two lyrics variants side by side
+// @Description Couplets are aligned by their index, missing couplets of the shorter variant are empty strings.
+// @Description Unlike other text endpoints there is no fallback to the original: a missing translation is 404
+// @Router /songs/{id}/text/align [get]
+// @Produce json
+// @Param id path int true "Song ID"
+// @Param left query string false "Language of the left column, original by default"
+// @Param right query string true "Language of the right column"
+// @Param limit query int false "Maximum number of couplets to return"
+// @Param page query int false "Page"
+// @Success 200 {object} TextAlignResponse
+// @Failure 400
+// @Failure 404
+// @Failure 500
+func (h *TextAlignHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	page, pageErr := ToInt(params.Get("page"))
+	limit, limitErr := ToInt(params.Get("limit"))
+	if pageErr != nil || limitErr != nil || page < 0 || limit < 0 || params.Get("right") == "" {
+		logger.Err.Println("bad request query")
+		http.Error(w, "right param is required, page and limit must be positive numbers", http.StatusBadRequest)
+		return
+	}
+
+	songId, _ := strconv.Atoi(mux.Vars(r)["id"])
+	song, err := h.SongsTable.Get(r.Context(), songId)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	leftText, leftLang, err := exactSongText(r.Context(), song, h.Translations, params.Get("left"))
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	rightText, rightLang, err := exactSongText(r.Context(), song, h.Translations, params.Get("right"))
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	left, right := lyrics.Couplets(leftText), lyrics.Couplets(rightText)
+	aligned := make([]AlignedCouplet, max(len(left), len(right)))
+	for i := range aligned {
+		aligned[i].Couplet = i
+		if i < len(left) {
+			aligned[i].Left = left[i]
+		}
+		if i < len(right) {
+			aligned[i].Right = right[i]
+		}
+	}
+
+	fragment, page, limit, ok := Paginate(aligned, page, limit)
+	if !ok {
+		logger.Err.Println("lyrics not found")
+		http.Error(w, "Couplets not found", http.StatusNotFound)
+		return
+	}
+
+	Render(w, r, &TextAlignResponse{
+		Left: leftLang,
+		Right: rightLang,
+		Couplets: fragment,
+		Page: page,
+		Limit: limit,
+	})
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"songsapi/logger"
+	"songsapi/storage"
+	"songsapi/webhooks"
+
+	"github.com/gorilla/mux"
+)
+
+// webhookEvents are the values accepted in webhook subscriptions.
+var webhookEvents = []string{
+	storage.EventSongCreated, storage.EventSongUpdated, storage.EventSongDeleted, "song.*",
+	storage.EventGroupCreated, storage.EventGroupUpdated, storage.EventGroupDeleted, storage.EventGroupMerged, "group.*",
+	"*",
+}
+
+type WebhooksHandler struct {
+	Hooks		storage.WebhookStorage
+	Dispatcher	*webhooks.Dispatcher
+}
+
+// WebhookRequest registers or changes the webhook. Empty secret is generated on
+// creation and left as it is on update, missing active means true.
+type WebhookRequest struct {
+	URL			string		`json:"url"`
+	Events		[]string	`json:"events"`
+	Secret		string		`json:"secret"`
+	Active		*bool		`json:"active"`
+}
+
+type WebhooksResponse struct {
+	Webhooks	[]*storage.Webhook
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries	[]*storage.WebhookDelivery
+}
+
+func (r *WebhookRequest) Validate() []string {
+	errs := make([]string, 0)
+	target, err := url.Parse(r.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		errs = append(errs, "url must be an absolute http or https URL")
+	}
+
+	if len(r.Events) == 0 {
+		errs = append(errs, "events are required")
+	}
+	for _, event := range r.Events {
+		if !slices.Contains(webhookEvents, event) {
+			errs = append(errs, fmt.Sprintf("unknown event %q, expected one of %s", event, strings.Join(webhookEvents, ", ")))
+		}
+	}
+
+	return errs
+}
+
+func (h *WebhooksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	switch {
+
+	case params["deliveryId"] != "":
+		h.redeliver(w, r, params)
+
+	case strings.HasSuffix(r.URL.Path, "/deliveries"):
+		h.deliveries(w, r, params)
+
+	case params["id"] != "" && r.Method == http.MethodGet:
+		h.get(w, r, params)
+
+	case params["id"] != "" && r.Method == http.MethodPut:
+		h.update(w, r, params)
+
+	case params["id"] != "":
+		h.delete(w, r, params)
+
+	case r.Method == http.MethodPost:
+		h.create(w, r)
+
+	default:
+		h.list(w, r)
+	}
+}
+
+// @Tags admin
+// @Summary Lists webhook subscriptions
+// @Description Secrets are not returned
+// @Router /admin/webhooks [get]
+// @Security AdminToken
+// @Failure 401
+// @Produce json
+// @Success 200 {object} WebhooksResponse
+// @Failure 500
+func (h *WebhooksHandler) list(w http.ResponseWriter, r *http.Request) {
+	hooks, err := h.Hooks.Webhooks(r.Context())
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	for _, hook := range hooks {
+		hook.Secret = ""
+	}
+	Render(w, r, &WebhooksResponse{ Webhooks: hooks })
+}
+
+// @Tags admin
+// @Summary Registers webhook subscription
+// @Description Events are song.created, song.updated, song.deleted, group.created, group.updated, group.deleted,
+// @Description group.merged, wildcards song.* and group.* or * for all of them. Deliveries are JSON POST requests
+// @Description signed with X-Webhook-Signature: sha256=HMAC-SHA256(secret, X-Webhook-Timestamp + "." + body).
+// @Description The secret is returned only here, it is generated when not given.
+// @Router /admin/webhooks [post]
+// @Security AdminToken
+// @Failure 401
+// @Accept json
+// @Produce json
+// @Param request body WebhookRequest true "Webhook subscription"
+// @Success 201 {object} storage.Webhook
+// @Failure 400
+// @Failure 500
+func (h *WebhooksHandler) create(w http.ResponseWriter, r *http.Request) {
+	var request WebhookRequest
+	defer r.Body.Close()
+	if err := PasreJSON(r.Body, &request); err != nil {
+		http.Error(w, "Can't parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if errs := request.Validate(); len(errs) > 0 {
+		http.Error(w, strings.Join(errs, "\n"), http.StatusBadRequest)
+		return
+	}
+
+	hook := &storage.Webhook{ URL: request.URL, Events: request.Events, Secret: request.Secret, Active: true }
+	if request.Active != nil {
+		hook.Active = *request.Active
+	}
+	if hook.Secret == "" {
+		hook.Secret = newWebhookSecret()
+	}
+
+	if err := h.Hooks.CreateWebhook(r.Context(), hook); err != nil {
+		http.Error(w, "Can't add webhook into database", http.StatusInternalServerError)
+		return
+	}
+
+	logger.Info.Printf("webhook %d registered for %s\n", hook.Id, strings.Join(hook.Events, ", "))
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/admin/webhooks/%d", hook.Id))
+	RenderStatus(w, r, http.StatusCreated, hook)
+}
+
+// @Tags admin
+// @Summary Returns webhook subscription
+// @Router /admin/webhooks/{id} [get]
+// @Security AdminToken
+// @Failure 401
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} storage.Webhook
+// @Failure 404
+// @Failure 500
+func (h *WebhooksHandler) get(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	id, _ := strconv.Atoi(params["id"])
+	hook, err := h.Hooks.GetWebhook(r.Context(), id)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	hook.Secret = ""
+	Render(w, r, hook)
+}
+
+// @Tags admin
+// @Summary Changes webhook subscription
+// @Description Deliveries already queued are sent to the new URL with the new secret
+// @Router /admin/webhooks/{id} [put]
+// @Security AdminToken
+// @Failure 401
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param request body WebhookRequest true "Webhook subscription"
+// @Success 200 {object} storage.Webhook
+// @Failure 400
+// @Failure 404
+// @Failure 500
+func (h *WebhooksHandler) update(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	id, _ := strconv.Atoi(params["id"])
+	var request WebhookRequest
+	defer r.Body.Close()
+	if err := PasreJSON(r.Body, &request); err != nil {
+		http.Error(w, "Can't parse request body", http.StatusBadRequest)
+		return
+	}
+
+	if errs := request.Validate(); len(errs) > 0 {
+		http.Error(w, strings.Join(errs, "\n"), http.StatusBadRequest)
+		return
+	}
+
+	hook, err := h.Hooks.GetWebhook(r.Context(), id)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	hook.URL, hook.Events = request.URL, request.Events
+	if request.Secret != "" {
+		hook.Secret = request.Secret
+	}
+	if request.Active != nil {
+		hook.Active = *request.Active
+	}
+
+	if err := h.Hooks.UpdateWebhook(r.Context(), hook); err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	hook.Secret = ""
+	Render(w, r, hook)
+}
+
+// @Tags admin
+// @Summary Deletes webhook subscription with its delivery log
+// @Router /admin/webhooks/{id} [delete]
+// @Security AdminToken
+// @Failure 401
+// @Param id path int true "Webhook ID"
+// @Success 204
+// @Failure 404
+// @Failure 500
+func (h *WebhooksHandler) delete(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	id, _ := strconv.Atoi(params["id"])
+	if err := h.Hooks.DeleteWebhook(r.Context(), id); err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Tags admin
+// @Summary Returns delivery log of the webhook
+// @Router /admin/webhooks/{id}/deliveries [get]
+// @Security AdminToken
+// @Failure 401
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param status query string false "Delivery status: pending, sending, retrying, delivered or failed"
+// @Param limit query int false "Maximum number of deliveries to return, default 100"
+// @Success 200 {object} WebhookDeliveriesResponse
+// @Failure 400
+// @Failure 500
+func (h *WebhooksHandler) deliveries(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	id, _ := strconv.Atoi(params["id"])
+	limit, err := ToInt(r.URL.Query().Get("limit"))
+	if err != nil {
+		logger.Err.Println("bad request query")
+		http.Error(w, "limit must be a number", http.StatusBadRequest)
+		return
+	}
+
+	if limit <= 0 {
+		limit = 100
+	}
+
+	deliveries, err := h.Hooks.Deliveries(r.Context(), id, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	Render(w, r, &WebhookDeliveriesResponse{ Deliveries: deliveries })
+}
+
+// @Tags admin
+// @Summary Sends the delivery once more
+// @Description A new delivery with the same payload is queued, the original one stays in the log
+// @Router /admin/webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+// @Security AdminToken
+// @Failure 401
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param deliveryId path int true "Delivery ID"
+// @Success 202 {object} storage.WebhookDelivery
+// @Failure 404
+// @Failure 500
+func (h *WebhooksHandler) redeliver(w http.ResponseWriter, r *http.Request, params map[string]string) {
+	id, _ := strconv.Atoi(params["id"])
+	deliveryId, _ := strconv.Atoi(params["deliveryId"])
+
+	delivery, err := h.Hooks.GetDelivery(r.Context(), deliveryId)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	if delivery.WebhookId != id {
+		http.Error(w, "Delivery not found", http.StatusNotFound)
+		return
+	}
+
+	delivery, err = h.Hooks.Redeliver(r.Context(), deliveryId)
+	if err != nil {
+		HandleDBSearchFail(w, err)
+		return
+	}
+
+	h.Dispatcher.Wake()
+	RenderStatus(w, r, http.StatusAccepted, delivery)
+}
+
+func newWebhookSecret() string {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return hex.EncodeToString(secret)
+}
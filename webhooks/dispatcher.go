@@ -0,0 +1,229 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"songsapi/config"
+	"songsapi/logger"
+	"songsapi/storage"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("songsapi/webhooks")
+
+type Config struct {
+	Workers			int
+	PollInterval	time.Duration
+	Lease			time.Duration
+	Timeout			time.Duration
+	MaxAttempts		int
+	RetryBackoff	time.Duration
+	MaxBackoff		time.Duration
+	AllowPrivate	bool
+}
+
+// Payload is the JSON body of every delivery. Id is the same for redeliveries
+// of the event, so receivers can drop duplicates.
+type Payload struct {
+	Id			string		`json:"id"`
+	Event		string		`json:"event"`
+	OccurredAt	time.Time	`json:"occurredAt"`
+	Data		any			`json:"data"`
+}
+
+// Dispatcher turns published events into webhook deliveries and sends them
+// with a pool of workers. Deliveries are kept in the database like enrichment jobs,
+// so they survive restarts and are shared between replicas.
+type Dispatcher struct {
+	Hooks		storage.WebhookStorage
+	Client		*http.Client
+	Config		Config
+
+	wake		chan struct{}
+}
+
+func ConfigFromEnv() Config {
+	return Config{
+		Workers: config.Int("WEBHOOK_WORKERS", 2),
+		PollInterval: config.Duration("WEBHOOK_POLL_INTERVAL", 5 * time.Second),
+		Lease: config.Duration("WEBHOOK_LEASE", time.Minute),
+		Timeout: config.Duration("WEBHOOK_TIMEOUT", 10 * time.Second),
+		MaxAttempts: config.Int("WEBHOOK_MAX_ATTEMPTS", 10),
+		RetryBackoff: config.Duration("WEBHOOK_RETRY_BACKOFF", 30 * time.Second),
+		MaxBackoff: config.Duration("WEBHOOK_MAX_BACKOFF", 6 * time.Hour),
+		AllowPrivate: config.Bool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+	}
+}
+
+func NewDispatcher(hooks storage.WebhookStorage, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		Hooks: hooks,
+		Client: guardedClient(cfg.Timeout, cfg.AllowPrivate),
+		Config: cfg,
+		wake: make(chan struct{}, cfg.Workers),
+	}
+}
+
+func (d *Dispatcher) Name() string {
+	return "webhooks"
+}
+
+// Publish queues deliveries of the outbox event for every subscribed webhook,
+// it is a sink of the outbox relay. Payload id is the outbox event id.
+func (d *Dispatcher) Publish(ctx context.Context, event *storage.OutboxEvent) error {
+	payload := &Payload{ Id: strconv.FormatInt(event.Id, 10), Event: event.Event, OccurredAt: event.CreatedAt.UTC(), Data: event.Data }
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	queued, err := d.Hooks.EnqueueDeliveries(ctx, event.Id, event.Event, body)
+	if err != nil {
+		return err
+	}
+
+	if queued > 0 {
+		d.Wake()
+	}
+	return nil
+}
+
+// Wake lets an idle worker pick up new deliveries without waiting for the poll interval.
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) Start(ctx context.Context) {
+	logger.Debug.Printf("starting %d webhook workers...\n", d.Config.Workers)
+	for i := 0; i < d.Config.Workers; i++ {
+		go d.work(ctx)
+	}
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	ticker := time.NewTicker(d.Config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for d.runOnce(ctx) {
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+func (d *Dispatcher) runOnce(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	delivery, err := d.Hooks.ClaimDelivery(ctx, d.Config.Lease)
+	if err != nil {
+		return false
+	}
+
+	ctx, span := tracer.Start(ctx, "webhook.delivery", trace.WithAttributes(attribute.Int("webhook.id", delivery.WebhookId),
+		attribute.Int("delivery.id", delivery.Id), attribute.String("delivery.event", delivery.Event),
+		attribute.Int("delivery.attempt", delivery.Attempts)))
+	defer span.End()
+
+	retryAfter := d.process(ctx, delivery)
+	span.SetAttributes(attribute.String("delivery.status", delivery.Status), attribute.Int("http.response.status_code", delivery.ResponseStatus))
+	if err := d.Hooks.FinishDelivery(ctx, delivery, retryAfter); err != nil {
+		logger.Err.Printf("can't save result of webhook delivery %d - %v\n", delivery.Id, err)
+	}
+
+	return true
+}
+
+func (d *Dispatcher) process(ctx context.Context, delivery *storage.WebhookDelivery) time.Duration {
+	hook, err := d.Hooks.GetWebhook(ctx, delivery.WebhookId)
+	if err == sql.ErrNoRows {
+		delivery.Status, delivery.LastError = storage.DeliveryFailed, "webhook was deleted"
+		return 0
+	}
+	if err != nil {
+		return d.retry(delivery, err)
+	}
+
+	if !hook.Active {
+		delivery.Status, delivery.LastError = storage.DeliveryFailed, "webhook is disabled"
+		return 0
+	}
+
+	if err := d.send(ctx, hook, delivery); err != nil {
+		return d.retry(delivery, err)
+	}
+
+	logger.Info.Printf("webhook delivery %d of %s sent to %s\n", delivery.Id, delivery.Event, hook.URL)
+	delivery.Status, delivery.LastError = storage.DeliveryDelivered, ""
+	return 0
+}
+
+func (d *Dispatcher) send(ctx context.Context, hook *storage.Webhook, delivery *storage.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "songsapi-webhooks")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.Id))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(hook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		delivery.ResponseStatus = 0
+		return err
+	}
+	defer resp.Body.Close()
+
+	// the response body isn't kept, the delivery log must not become a way to read it
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64 << 10))
+	delivery.ResponseStatus = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// retry schedules the next attempt with exponential backoff, the delivery fails
+// after MaxAttempts and can only be sent again by manual redelivery.
+func (d *Dispatcher) retry(delivery *storage.WebhookDelivery, err error) time.Duration {
+	if d.Config.MaxAttempts > 0 && delivery.Attempts >= d.Config.MaxAttempts {
+		logger.Err.Printf("webhook delivery %d failed - %v\n", delivery.Id, err)
+		delivery.Status, delivery.LastError = storage.DeliveryFailed, err.Error()
+		return 0
+	}
+
+	backoff := d.Config.RetryBackoff << min(delivery.Attempts - 1, 16)
+	if backoff > d.Config.MaxBackoff || backoff <= 0 {
+		backoff = d.Config.MaxBackoff
+	}
+
+	logger.Warn.Printf("webhook delivery %d failed, retry in %s - %v\n", delivery.Id, backoff, err)
+	delivery.Status, delivery.LastError = storage.DeliveryRetrying, err.Error()
+	return backoff
+}
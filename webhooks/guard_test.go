@@ -0,0 +1,63 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr	string
+		want	bool
+	}{
+		{ "93.184.216.34", true },
+		{ "2606:2800:220:1:248:1893:25c8:1946", true },
+		{ "127.0.0.1", false },
+		{ "::1", false },
+		{ "10.0.0.5", false },
+		{ "172.16.3.4", false },
+		{ "192.168.1.1", false },
+		{ "169.254.169.254", false },
+		{ "100.64.0.1", false },
+		{ "0.0.0.0", false },
+		{ "fd00::1", false },
+		{ "fe80::1", false },
+		{ "::ffff:127.0.0.1", false },
+		{ "::ffff:10.1.2.3", false },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := PublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("PublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuardedClient(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/internal", http.StatusFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	if _, err := guardedClient(time.Second, false).Get(target.URL); err == nil {
+		t.Error("loopback address was called")
+	}
+
+	resp, err := guardedClient(time.Second, true).Get(target.URL + "/redirect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("status = %d, redirect was followed", resp.StatusCode)
+	}
+}
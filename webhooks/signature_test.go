@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name		string
+		secret		string
+		timestamp	int64
+		body		string
+		want		string
+	}{
+		// expected values are computed by `printf '<timestamp>.<body>' | openssl dgst -sha256 -hmac <secret>`
+		{
+			name: "json body",
+			secret: "secret",
+			timestamp: 1700000000,
+			body: `{"id":1}`,
+			want: "sha256=3dd1b9aef568d75f6790a84bd2e5dfa1f44409eef3cbdbd3f10b837376100c11",
+		},
+		{
+			name: "empty body",
+			secret: "secret",
+			timestamp: 1700000000,
+			body: "",
+			want: "sha256=4bc5f74d868b97888288889c5d9d65df02526f94c1592a79fdf4fe8b26e311e5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+				t.Errorf("Sign() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"event":"song.updated"}`)
+	now := time.Now().Unix()
+	timestamp := strconv.FormatInt(now, 10)
+	signature := Sign("secret", now, body)
+
+	tests := []struct {
+		name		string
+		secret		string
+		timestamp	string
+		signature	string
+		body		[]byte
+		want		bool
+	}{
+		{ name: "valid", secret: "secret", timestamp: timestamp, signature: signature, body: body, want: true },
+		{ name: "surrounding spaces", secret: "secret", timestamp: timestamp, signature: " " + signature + "\n", body: body, want: true },
+		{ name: "wrong secret", secret: "other", timestamp: timestamp, signature: signature, body: body },
+		{ name: "changed body", secret: "secret", timestamp: timestamp, signature: signature, body: []byte(`{"event":"song.deleted"}`) },
+		{ name: "changed timestamp", secret: "secret", timestamp: strconv.FormatInt(now + 1, 10), signature: signature, body: body },
+		{ name: "bad timestamp", secret: "secret", timestamp: "yesterday", signature: signature, body: body },
+		{
+			name: "replayed",
+			secret: "secret",
+			timestamp: strconv.FormatInt(now - 3600, 10),
+			signature: Sign("secret", now - 3600, body),
+			body: body,
+		},
+		{ name: "missing prefix", secret: "secret", timestamp: timestamp, signature: signature[len("sha256="):], body: body },
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.timestamp, tt.signature, tt.body, 5 * time.Minute); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}